@@ -0,0 +1,383 @@
+package goat
+
+import "strings"
+
+type labelOp int
+
+const (
+	labelTrue labelOp = iota
+	labelCond
+	labelNot
+	labelAnd
+	labelOr
+)
+
+// Label is a boolean expression over conditions that guards an automaton
+// transition. A transition can be taken from a world when its label
+// evaluates to true against the conditions holding in that world.
+type Label struct {
+	op   labelOp
+	cond Condition
+	args []*Label
+}
+
+// LabelTrue returns a label that matches every world.
+func LabelTrue() *Label {
+	return &Label{op: labelTrue}
+}
+
+// LabelOf returns a label that matches worlds where c holds.
+func LabelOf(c Condition) *Label {
+	return &Label{op: labelCond, cond: c}
+}
+
+// LabelNot returns a label that matches worlds where l does not match.
+func LabelNot(l *Label) *Label {
+	return &Label{op: labelNot, args: []*Label{l}}
+}
+
+// LabelAnd returns a label that matches worlds where every label matches.
+func LabelAnd(ls ...*Label) *Label {
+	return &Label{op: labelAnd, args: ls}
+}
+
+// LabelOr returns a label that matches worlds where any label matches.
+func LabelOr(ls ...*Label) *Label {
+	return &Label{op: labelOr, args: ls}
+}
+
+// String returns the label using HOA-style operators.
+func (l *Label) String() string {
+	switch l.op {
+	case labelCond:
+		return l.cond.Name().String()
+	case labelNot:
+		return "!" + l.args[0].String()
+	case labelAnd, labelOr:
+		// Empty conjunctions match every world, empty disjunctions none.
+		if len(l.args) == 0 && l.op == labelOr {
+			return "f"
+		}
+		if len(l.args) == 0 {
+			return "t"
+		}
+		sep := " & "
+		if l.op == labelOr {
+			sep = " | "
+		}
+		parts := make([]string, len(l.args))
+		for i, arg := range l.args {
+			parts[i] = arg.String()
+		}
+		return "(" + strings.Join(parts, sep) + ")"
+	default:
+		return "t"
+	}
+}
+
+func (l *Label) eval(labels map[ConditionName]bool) bool {
+	switch l.op {
+	case labelCond:
+		return labels[l.cond.Name()]
+	case labelNot:
+		return !l.args[0].eval(labels)
+	case labelAnd:
+		for _, arg := range l.args {
+			if !arg.eval(labels) {
+				return false
+			}
+		}
+		return true
+	case labelOr:
+		for _, arg := range l.args {
+			if arg.eval(labels) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func (l *Label) conditions() []Condition {
+	if l.op == labelCond {
+		return []Condition{l.cond}
+	}
+	var conds []Condition
+	for _, arg := range l.args {
+		conds = append(conds, arg.conditions()...)
+	}
+	return conds
+}
+
+type automatonTransition struct {
+	to    int
+	label *Label
+}
+
+type automaton struct {
+	initial int
+	marked  map[int]bool
+	trans   map[int][]automatonTransition
+}
+
+func newAutomaton(initial int) automaton {
+	return automaton{
+		initial: initial,
+		marked:  make(map[int]bool),
+		trans:   make(map[int][]automatonTransition),
+	}
+}
+
+func (a *automaton) addTransition(from, to int, label *Label) {
+	if label == nil {
+		label = LabelTrue()
+	}
+	a.trans[from] = append(a.trans[from], automatonTransition{to: to, label: label})
+}
+
+func (a *automaton) conditions() []Condition {
+	var conds []Condition
+	for _, trs := range a.trans {
+		for _, tr := range trs {
+			conds = append(conds, tr.label.conditions()...)
+		}
+	}
+	return conds
+}
+
+// BuchiAutomaton is an explicit Büchi automaton over condition labels.
+//
+// The automaton describes the behaviors that violate a property, in the same
+// way an LTL-to-automaton translator produces an automaton for the negated
+// formula: a run that visits an accepting state infinitely often is reported
+// as a violation. At each step the automaton reads the conditions of the
+// current world and moves along every transition whose label matches.
+type BuchiAutomaton struct {
+	automaton
+}
+
+// NewBuchiAutomaton creates an empty Büchi automaton starting in initial.
+//
+// Example:
+//
+//	// Violations of "whenever p, eventually q": p && !q, then !q forever.
+//	a := goat.NewBuchiAutomaton(0).
+//		Transition(0, 0, goat.LabelTrue()).
+//		Transition(0, 1, goat.LabelAnd(goat.LabelOf(p), goat.LabelNot(goat.LabelOf(q)))).
+//		Transition(1, 1, goat.LabelNot(goat.LabelOf(q))).
+//		Accepting(1)
+func NewBuchiAutomaton(initial int) *BuchiAutomaton {
+	return &BuchiAutomaton{automaton: newAutomaton(initial)}
+}
+
+// Transition adds a transition from one automaton state to another that can
+// be taken in worlds matching label. A nil label matches every world.
+//
+// Returns the automaton for method chaining.
+func (a *BuchiAutomaton) Transition(from, to int, label *Label) *BuchiAutomaton {
+	a.addTransition(from, to, label)
+	return a
+}
+
+// Accepting marks states as accepting. Runs that visit an accepting state
+// infinitely often are violations.
+//
+// Returns the automaton for method chaining.
+func (a *BuchiAutomaton) Accepting(states ...int) *BuchiAutomaton {
+	for _, s := range states {
+		a.marked[s] = true
+	}
+	return a
+}
+
+func (a *BuchiAutomaton) ba() *ba {
+	b := &ba{
+		initial:   baState(a.initial),
+		accepting: make(map[baState]bool, len(a.marked)),
+		trans:     make(map[baState][]baTransition, len(a.trans)),
+	}
+	for s := range a.marked {
+		b.accepting[baState(s)] = true
+	}
+	for from, trs := range a.trans {
+		for _, tr := range trs {
+			b.trans[baState(from)] = append(b.trans[baState(from)], baTransition{
+				to:   baState(tr.to),
+				cond: tr.label.eval,
+			})
+		}
+	}
+	return b
+}
+
+// BuchiRule returns a rule that reports a violation whenever the explored
+// system has an infinite run accepted by a.
+//
+// Worlds without successors are treated as looping on themselves, so the
+// automaton also observes runs that end in a terminal world.
+//
+// Parameters:
+//   - name: Name reported for the rule
+//   - a: Automaton accepting the violating behaviors
+//
+// Returns a Rule that can be registered with WithRules.
+//
+// Example:
+//
+//	err := goat.Test(
+//		goat.WithStateMachines(client, server),
+//		goat.WithRules(goat.BuchiRule("request answered", a)),
+//	)
+func BuchiRule(name string, a *BuchiAutomaton) Rule {
+	return ruleFunc(func(o *options) {
+		var operands []ConditionName
+		for _, c := range a.conditions() {
+			registerCondition(o, c)
+			operands = append(operands, c.Name())
+		}
+		registerTemporalRule(o, ltlRule{n: name, b: a.ba(), operands: operands})
+	})
+}
+
+// SafetyObserver is a finite automaton over condition labels that watches
+// every path of the system. Reaching a bad state is a violation, reported
+// with the finite path that led to it.
+//
+// At each step the observer reads the conditions of the current world and
+// moves along every transition whose label matches. When no transition
+// matches, that run of the observer stops without a violation; add a
+// LabelTrue self-loop to keep a state active.
+type SafetyObserver struct {
+	automaton
+}
+
+// NewSafetyObserver creates an empty safety observer starting in initial.
+//
+// Example:
+//
+//	// A result must never be delivered before a request was sent.
+//	o := goat.NewSafetyObserver(0).
+//		Transition(0, 0, goat.LabelNot(goat.LabelOf(requested))).
+//		Transition(0, 1, goat.LabelOf(requested)).
+//		Transition(0, 2, goat.LabelAnd(goat.LabelOf(delivered), goat.LabelNot(goat.LabelOf(requested)))).
+//		Bad(2)
+func NewSafetyObserver(initial int) *SafetyObserver {
+	return &SafetyObserver{automaton: newAutomaton(initial)}
+}
+
+// Transition adds a transition from one observer state to another that can
+// be taken in worlds matching label. A nil label matches every world.
+//
+// Returns the observer for method chaining.
+func (o *SafetyObserver) Transition(from, to int, label *Label) *SafetyObserver {
+	o.addTransition(from, to, label)
+	return o
+}
+
+// Bad marks states whose reachability is a violation.
+//
+// Returns the observer for method chaining.
+func (o *SafetyObserver) Bad(states ...int) *SafetyObserver {
+	for _, s := range states {
+		o.marked[s] = true
+	}
+	return o
+}
+
+// ObserverRule returns a rule that reports a violation when o can reach a
+// bad state along some path of the explored system.
+//
+// Parameters:
+//   - name: Name reported for the rule
+//   - o: Observer whose bad states must stay unreachable
+//
+// Returns a Rule that can be registered with WithRules.
+//
+// Example:
+//
+//	err := goat.Test(
+//		goat.WithStateMachines(client, server),
+//		goat.WithRules(goat.ObserverRule("no early result", o)),
+//	)
+func ObserverRule(name string, o *SafetyObserver) Rule {
+	return ruleFunc(func(opts *options) {
+		for _, c := range o.conditions() {
+			registerCondition(opts, c)
+		}
+		opts.observerRules = append(opts.observerRules, observerRule{n: name, o: &o.automaton})
+	})
+}
+
+type observerRule struct {
+	n string
+	o *automaton
+}
+
+func (r observerRule) name() string { return r.n }
+
+// finitePath is a finite sequence of worlds starting at the initial world.
+type finitePath struct {
+	Path []worldID `json:"path"`
+}
+
+func (*finitePath) temporalEvidence() {}
+
+func (m *model) checkObservers() []temporalRuleResult {
+	results := make([]temporalRuleResult, 0, len(m.observerRules))
+	for _, r := range m.observerRules {
+		result := temporalRuleResult{Rule: r.name(), Satisfied: true}
+		if path := m.findObserverViolation(r.o); path != nil {
+			m.hasTemporalViolation = true
+			result.Satisfied = false
+			result.Evidence = &finitePath{Path: path}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// findObserverViolation searches the product of the model and the observer
+// breadth-first, so the returned path is a shortest one. Each product node
+// pairs a world with the observer state before reading that world. An
+// observer starting in a bad state is violated at the initial world.
+func (m *model) findObserverViolation(o *automaton) []worldID {
+	if o.marked[o.initial] {
+		return []worldID{m.initial.id}
+	}
+	type node struct {
+		w worldID
+		s int
+	}
+	start := node{w: m.initial.id, s: o.initial}
+	pre := map[node]node{start: start}
+	queue := []node{start}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, tr := range o.trans[n.s] {
+			if !tr.label.eval(m.labels[n.w]) {
+				continue
+			}
+			if o.marked[tr.to] {
+				path := []worldID{n.w}
+				for cur := n; pre[cur] != cur; {
+					cur = pre[cur]
+					path = append([]worldID{cur.w}, path...)
+				}
+				return path
+			}
+			for _, w2 := range m.accessible[n.w] {
+				next := node{w: w2, s: tr.to}
+				if _, seen := pre[next]; seen {
+					continue
+				}
+				pre[next] = n
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
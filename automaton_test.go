@@ -0,0 +1,163 @@
+package goat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBuchiRule(t *testing.T) {
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{
+			name: "holds when accepting state is unreachable",
+			cond: BoolCondition("c", true),
+			want: true,
+		},
+		{
+			name: "violation when accepting state loops",
+			cond: BoolCondition("c", false),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Violations of "eventually always c": !c infinitely often.
+			a := NewBuchiAutomaton(0).
+				Transition(0, 0, LabelTrue()).
+				Transition(0, 1, LabelNot(LabelOf(tt.cond))).
+				Transition(1, 0, nil).
+				Accepting(1)
+
+			sm := newTestStateMachine(newTestState("s"))
+			m := newSolvedTestModel(t,
+				WithStateMachines(sm),
+				WithRules(BuchiRule("custom", a)),
+			)
+			res := m.checkTemporalRules()
+			if len(res) != 1 {
+				t.Fatalf("expected one result, got %d", len(res))
+			}
+			if res[0].Rule != "custom" {
+				t.Errorf("Rule = %q, want %q", res[0].Rule, "custom")
+			}
+			if res[0].Satisfied != tt.want {
+				t.Fatalf("Satisfied = %v, want %v", res[0].Satisfied, tt.want)
+			}
+			if !tt.want {
+				if l, ok := res[0].Evidence.(*lasso); !ok || l == nil {
+					t.Fatalf("expected lasso")
+				}
+			}
+		})
+	}
+}
+
+func TestObserverRule(t *testing.T) {
+	t.Run("holds when bad state is unreachable", func(t *testing.T) {
+		sm, _, inB := newTwoStateTestMachine(false)
+		never := BoolCondition("never", false)
+		o := NewSafetyObserver(0).
+			Transition(0, 0, LabelTrue()).
+			Transition(0, 1, LabelAnd(LabelOf(inB), LabelOf(never))).
+			Bad(1)
+		m := newSolvedTestModel(t,
+			WithStateMachines(sm),
+			WithRules(ObserverRule("observer", o)),
+		)
+		res := m.checkObservers()
+		if !res[0].Satisfied || res[0].Evidence != nil {
+			t.Fatalf("expected rule to hold, got %+v", res[0])
+		}
+		if m.hasTemporalViolation {
+			t.Errorf("unexpected temporal violation")
+		}
+	})
+
+	t.Run("reports initial bad state", func(t *testing.T) {
+		sm, _, _ := newTwoStateTestMachine(false)
+		o := NewSafetyObserver(0).Bad(0)
+		m := newSolvedTestModel(t,
+			WithStateMachines(sm),
+			WithRules(ObserverRule("bad from the start", o)),
+		)
+		res := m.checkObservers()
+		if res[0].Satisfied || !m.hasTemporalViolation {
+			t.Fatalf("expected violation, got %+v", res[0])
+		}
+		path, ok := res[0].Evidence.(*finitePath)
+		if !ok || len(path.Path) != 1 || path.Path[0] != m.initial.id {
+			t.Errorf("expected path with the initial world only, got %+v", res[0].Evidence)
+		}
+	})
+
+	t.Run("reports shortest path to bad state", func(t *testing.T) {
+		sm, _, inB := newTwoStateTestMachine(false)
+		o := NewSafetyObserver(0).
+			Transition(0, 0, LabelNot(LabelOf(inB))).
+			Transition(0, 1, LabelOf(inB)).
+			Bad(1)
+		m := newSolvedTestModel(t,
+			WithStateMachines(sm),
+			WithRules(ObserverRule("never B", o)),
+		)
+		res := m.checkObservers()
+		if res[0].Satisfied {
+			t.Fatalf("expected violation")
+		}
+		path, ok := res[0].Evidence.(*finitePath)
+		if !ok {
+			t.Fatalf("expected finitePath evidence, got %T", res[0].Evidence)
+		}
+		if path.Path[0] != m.initial.id {
+			t.Errorf("path must start at the initial world")
+		}
+		last := path.Path[len(path.Path)-1]
+		if !m.labels[last][inB.Name()] {
+			t.Errorf("path must end in a world where B holds")
+		}
+		for _, id := range path.Path[:len(path.Path)-1] {
+			if m.labels[id][inB.Name()] {
+				t.Errorf("path must stop at the first world where B holds")
+			}
+		}
+
+		var buf bytes.Buffer
+		m.writeTemporalViolations(&buf, res)
+		if !strings.HasPrefix(buf.String(), "Condition failed. Not never B.\nViolation path (length = 4):\n") {
+			t.Errorf("unexpected output:\n%s", buf.String())
+		}
+		if !strings.Contains(buf.String(), "<-- violation here") {
+			t.Errorf("expected violation marker in output:\n%s", buf.String())
+		}
+	})
+}
+
+func TestLabel_String(t *testing.T) {
+	p := LabelOf(BoolCondition("p", true))
+	q := LabelOf(BoolCondition("q", true))
+	tests := []struct {
+		l    *Label
+		want string
+	}{
+		{LabelTrue(), "t"},
+		{LabelNot(p), "!p"},
+		{LabelAnd(p, LabelOr(q, LabelNot(p))), "(p & (q | !p))"},
+		{LabelAnd(), "t"},
+		{LabelOr(), "f"},
+	}
+	for _, tt := range tests {
+		if got := tt.l.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+
+	// The printed constant matches what empty labels evaluate to.
+	if !LabelAnd().eval(nil) || LabelOr().eval(nil) {
+		t.Errorf("LabelAnd() must match every world and LabelOr() none")
+	}
+}
@@ -0,0 +1,218 @@
+package goat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// WithinSteps returns a rule enforcing that whenever p holds, q holds within
+// n steps on every path. Paths on which q never holds after p, including
+// paths that end in a terminal world or loop forever, exceed every bound.
+//
+// Parameters:
+//   - p: Condition that starts the deadline
+//   - q: Condition that must hold at most n steps later
+//   - n: Maximum number of steps between p and q
+//
+// Returns a Rule that can be registered with WithRules. Its result reports
+// the longest distance observed between p and q, and the path realizing it
+// when the bound is exceeded.
+//
+// Example:
+//
+//	err := goat.Test(
+//		goat.WithStateMachines(client, server),
+//		goat.WithRules(goat.WithinSteps(requested, answered, 4)),
+//	)
+func WithinSteps(p, q Condition, n int) Rule {
+	name := fmt.Sprintf("%s within %d steps of %s", q.Name(), n, p.Name())
+	return ruleFunc(func(o *options) {
+		registerCondition(o, p)
+		registerCondition(o, q)
+		o.boundedRules = append(o.boundedRules, boundedRule{
+			n:       name,
+			trigger: p.Name(),
+			target:  func(l map[ConditionName]bool) bool { return l[q.Name()] },
+			reached: fmt.Sprintf("%s holds", q.Name()),
+			bound:   n,
+		})
+	})
+}
+
+// BoundedBy returns a rule enforcing that c never holds for more than n
+// consecutive steps. It is useful for conditions describing temporary
+// situations, such as a request being in flight or a lock being held.
+//
+// Parameters:
+//   - c: Condition that may only hold temporarily
+//   - n: Maximum number of consecutive steps c may hold
+//
+// Returns a Rule that can be registered with WithRules.
+//
+// Example:
+//
+//	err := goat.Test(
+//		goat.WithStateMachines(nodes...),
+//		goat.WithRules(goat.BoundedBy(locked, 3)),
+//	)
+func BoundedBy(c Condition, n int) Rule {
+	name := fmt.Sprintf("%s for at most %d consecutive steps", c.Name(), n)
+	return ruleFunc(func(o *options) {
+		registerCondition(o, c)
+		o.boundedRules = append(o.boundedRules, boundedRule{
+			n:       name,
+			trigger: c.Name(),
+			target:  func(l map[ConditionName]bool) bool { return !l[c.Name()] },
+			reached: fmt.Sprintf("%s no longer holds", c.Name()),
+			bound:   n,
+		})
+	})
+}
+
+type boundedRule struct {
+	n       string
+	trigger ConditionName
+	target  func(map[ConditionName]bool) bool
+	reached string
+	bound   int
+}
+
+func (r boundedRule) name() string { return r.n }
+
+// boundedTrace reports the longest distance between a trigger and its target
+// and, when the bound is exceeded, a path realizing it.
+type boundedTrace struct {
+	Bound     int       `json:"bound"`
+	Longest   int       `json:"longest_distance"`
+	Unbounded bool      `json:"unbounded,omitempty"`
+	Path      []worldID `json:"path,omitempty"`
+	// Trigger is the index in Path of the world where the trigger holds.
+	Trigger int `json:"trigger_index,omitempty"`
+
+	trigger ConditionName
+	reached string
+}
+
+func (*boundedTrace) temporalEvidence() {}
+
+const unboundedDistance = math.MaxInt
+
+func (m *model) checkBounded() []temporalRuleResult {
+	results := make([]temporalRuleResult, 0, len(m.boundedRules))
+	for _, r := range m.boundedRules {
+		trace := m.longestResponse(r)
+		result := temporalRuleResult{Rule: r.name(), Satisfied: true, Evidence: trace}
+		if trace.Unbounded || trace.Longest > r.bound {
+			m.hasTemporalViolation = true
+			result.Satisfied = false
+		} else {
+			result.Warnings = m.vacuityWarnings([]ConditionName{r.trigger}, nil)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// longestResponse finds the step where r's trigger holds that is followed by
+// the longest wait for its target, and the path realizing that wait when it
+// exceeds the bound.
+func (m *model) longestResponse(r boundedRule) *boundedTrace {
+	dist := m.distances(r.target)
+	edgeDist := func(e labeledEdge) int {
+		if r.target(e.labels) {
+			return 0
+		}
+		if d := dist(e.to); d != unboundedDistance {
+			return d + 1
+		}
+		return unboundedDistance
+	}
+
+	worldIDs := make([]worldID, 0, len(m.worlds))
+	for id := range m.worlds {
+		worldIDs = append(worldIDs, id)
+	}
+	sort.Slice(worldIDs, func(i, j int) bool { return worldIDs[i] < worldIDs[j] })
+
+	longest := -1
+	var from worldID
+	var first labeledEdge
+	for _, w := range worldIDs {
+		for _, e := range m.outgoing(w) {
+			if !e.labels[r.trigger] {
+				continue
+			}
+			if d := edgeDist(e); d > longest {
+				longest, from, first = d, w, e
+			}
+		}
+	}
+
+	trace := &boundedTrace{Bound: r.bound, trigger: r.trigger, reached: r.reached}
+	if longest < 0 {
+		return trace
+	}
+	if longest == unboundedDistance {
+		trace.Unbounded = true
+	} else {
+		trace.Longest = longest
+	}
+	if !trace.Unbounded && longest <= r.bound {
+		return trace
+	}
+
+	// Follow the longest wait from the trigger until the target holds or a
+	// world repeats.
+	path := pathTo(m.shortestPaths(), from)
+	trace.Trigger = len(path) - 1
+	seen := map[worldID]bool{from: true}
+	for e := first; !r.target(e.labels) && !seen[e.to]; {
+		path = append(path, e.to)
+		seen[e.to] = true
+		next := m.outgoing(e.to)
+		e = next[0]
+		for _, candidate := range next[1:] {
+			if edgeDist(candidate) > edgeDist(e) {
+				e = candidate
+			}
+		}
+	}
+	trace.Path = path
+	return trace
+}
+
+// distances returns, for each world, the largest number of steps any path
+// from it takes before reaching an edge satisfying target. Worlds from which
+// some path avoids target forever have distance unboundedDistance.
+func (m *model) distances(target func(map[ConditionName]bool) bool) func(worldID) int {
+	dist := make(map[worldID]int)
+	onStack := make(map[worldID]bool)
+
+	var visit func(w worldID) int
+	visit = func(w worldID) int {
+		if d, ok := dist[w]; ok {
+			return d
+		}
+		if onStack[w] {
+			return unboundedDistance
+		}
+		onStack[w] = true
+		d := 0
+		for _, e := range m.outgoing(w) {
+			if target(e.labels) {
+				continue
+			}
+			next := visit(e.to)
+			if next == unboundedDistance {
+				d = unboundedDistance
+				break
+			}
+			d = max(d, next+1)
+		}
+		delete(onStack, w)
+		dist[w] = d
+		return d
+	}
+	return visit
+}
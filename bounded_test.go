@@ -0,0 +1,115 @@
+package goat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBoundedRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		loop          bool
+		rule          func(inA, inB Condition) Rule
+		wantSatisfied bool
+		wantLongest   int
+		wantUnbounded bool
+	}{
+		{
+			name:          "B within 3 steps of A",
+			loop:          false,
+			rule:          func(inA, inB Condition) Rule { return WithinSteps(inA, inB, 3) },
+			wantSatisfied: true,
+			wantLongest:   3,
+		},
+		{
+			name:          "B not within 2 steps of A",
+			loop:          false,
+			rule:          func(inA, inB Condition) Rule { return WithinSteps(inA, inB, 2) },
+			wantSatisfied: false,
+			wantLongest:   3,
+		},
+		{
+			name:          "A never returns when B is terminal",
+			loop:          false,
+			rule:          func(inA, inB Condition) Rule { return WithinSteps(inB, inA, 10) },
+			wantSatisfied: false,
+			wantUnbounded: true,
+		},
+		{
+			name:          "B bounded on a loop",
+			loop:          true,
+			rule:          func(_, inB Condition) Rule { return BoundedBy(inB, 3) },
+			wantSatisfied: true,
+			wantLongest:   3,
+		},
+		{
+			name:          "B exceeds bound on a loop",
+			loop:          true,
+			rule:          func(_, inB Condition) Rule { return BoundedBy(inB, 2) },
+			wantSatisfied: false,
+			wantLongest:   3,
+		},
+		{
+			name:          "B unbounded when terminal",
+			loop:          false,
+			rule:          func(_, inB Condition) Rule { return BoundedBy(inB, 5) },
+			wantSatisfied: false,
+			wantUnbounded: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, inA, inB := newTwoStateTestMachine(tt.loop)
+			m := newSolvedTestModel(t,
+				WithStateMachines(sm),
+				WithRules(tt.rule(inA, inB)),
+			)
+			res := m.checkBounded()
+			if len(res) != 1 {
+				t.Fatalf("expected one result, got %d", len(res))
+			}
+			if res[0].Satisfied != tt.wantSatisfied {
+				t.Errorf("Satisfied = %v, want %v", res[0].Satisfied, tt.wantSatisfied)
+			}
+			trace, ok := res[0].Evidence.(*boundedTrace)
+			if !ok {
+				t.Fatalf("expected boundedTrace evidence, got %T", res[0].Evidence)
+			}
+			if trace.Unbounded != tt.wantUnbounded {
+				t.Errorf("Unbounded = %v, want %v", trace.Unbounded, tt.wantUnbounded)
+			}
+			if !tt.wantUnbounded && trace.Longest != tt.wantLongest {
+				t.Errorf("Longest = %d, want %d", trace.Longest, tt.wantLongest)
+			}
+			if tt.wantSatisfied != (len(trace.Path) == 0) {
+				t.Errorf("unexpected path %v", trace.Path)
+			}
+		})
+	}
+}
+
+func TestBoundedRules_output(t *testing.T) {
+	sm, inA, inB := newTwoStateTestMachine(false)
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithRules(WithinSteps(inA, inB, 2)),
+	)
+	res := m.checkTemporalRules()
+
+	var buf bytes.Buffer
+	m.writeTemporalViolations(&buf, res)
+	got := buf.String()
+
+	wantHeader := "Condition failed. Not inB within 2 steps of inA.\n" +
+		"Longest observed distance: 3 steps (bound = 2)\n" +
+		"Violation path (length = 4):\n" +
+		"  [0] <-- inA holds\n"
+	if !strings.HasPrefix(got, wantHeader) {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+	if !strings.Contains(got, "  [3] <-- inB holds\n") {
+		t.Errorf("expected path to end where inB holds:\n%s", got)
+	}
+}
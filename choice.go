@@ -0,0 +1,140 @@
+package goat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type choiceRecord struct {
+	n     int
+	index int
+	value any
+}
+
+// Choose picks one of options nondeterministically. Exploration forks into
+// one successor per option, and the chosen value is shown on the step in
+// traces and DOT output. A handler may choose several times; every
+// combination of choices is explored.
+//
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - options: The values to choose from; at least one is required
+//
+// Returns the chosen value.
+//
+// Example:
+//
+//	goat.OnEvent(spec, &Idle{}, func(ctx context.Context, e *RequestEvent, sm *Server) {
+//	    if goat.Choose(ctx, true, false) {
+//	        goat.SendTo(ctx, e.Sender(), &AcceptedEvent{})
+//	    } else {
+//	        goat.SendTo(ctx, e.Sender(), &RejectedEvent{})
+//	    }
+//	})
+func Choose[V any](ctx context.Context, options ...V) V {
+	if len(options) == 0 {
+		panic("Choose called without options")
+	}
+	env := getEnvFromContext(ctx)
+	i := env.choose(len(options))
+	env.step.choices[len(env.step.choices)-1].value = options[i]
+	return options[i]
+}
+
+// ChooseInt picks an integer between lo and hi, both included,
+// nondeterministically. See Choose.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - lo: The smallest value
+//   - hi: The largest value; must not be smaller than lo
+//
+// Returns the chosen value.
+//
+// Example:
+//
+//	goat.OnEntry(spec, &Idle{}, func(ctx context.Context, sm *Client) {
+//	    goat.SendTo(ctx, server, &ReserveEvent{RoomID: goat.ChooseInt(ctx, 1, 3)})
+//	})
+func ChooseInt(ctx context.Context, lo, hi int) int {
+	if hi < lo {
+		panic(fmt.Sprintf("ChooseInt called with empty range [%d, %d]", lo, hi))
+	}
+	env := getEnvFromContext(ctx)
+	v := lo + env.choose(hi-lo+1)
+	env.step.choices[len(env.step.choices)-1].value = v
+	return v
+}
+
+// choose records a choice between n alternatives and returns the index
+// taken: the one prescribed by the script of the current run, or 0 for
+// choices beyond it.
+func (e *environment) choose(n int) int {
+	if e.step == nil {
+		e.step = &stepRecord{}
+	}
+	index := 0
+	if i := len(e.step.choices); i < len(e.step.script) {
+		index = e.step.script[i]
+	}
+	e.step.choices = append(e.step.choices, choiceRecord{n: n, index: index})
+	return index
+}
+
+// forEachChoice runs a handler on copies of env, once for every sequence of
+// choices it can make with Choose, and returns the resulting local states.
+func forEachChoice(env environment, run func(ec *environment)) []localState {
+	lss := make([]localState, 0)
+	scripts := [][]int{nil}
+	for len(scripts) > 0 {
+		script := scripts[0]
+		scripts = scripts[1:]
+
+		ec := env.clone()
+		if script != nil {
+			ec.step = &stepRecord{script: script}
+		}
+		run(&ec)
+		lss = append(lss, localState{env: ec})
+
+		if ec.step == nil {
+			continue
+		}
+		made := ec.step.choices
+		for i := len(script); i < len(made); i++ {
+			for alt := 1; alt < made[i].n; alt++ {
+				next := make([]int, i+1)
+				for j := range i {
+					next[j] = made[j].index
+				}
+				next[i] = alt
+				scripts = append(scripts, next)
+			}
+		}
+	}
+	return lss
+}
+
+// chosenValues returns the values chosen during a step.
+func chosenValues(choices []choiceRecord) []any {
+	if len(choices) == 0 {
+		return nil
+	}
+	values := make([]any, len(choices))
+	for i, c := range choices {
+		values[i] = c.value
+	}
+	return values
+}
+
+func formatChoices(values []any) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = fmt.Sprintf("%+v", v)
+	}
+	return strings.Join(strs, ", ")
+}
@@ -0,0 +1,120 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChoose(t *testing.T) {
+	type choiceStateMachine struct {
+		StateMachine
+		Flag  bool
+		Value int
+	}
+
+	tests := []struct {
+		name      string
+		handler   func(ctx context.Context, sm *choiceStateMachine)
+		wantEdges []string
+	}{
+		{
+			name: "no choice",
+			handler: func(_ context.Context, sm *choiceStateMachine) {
+				sm.Value = 1
+			},
+			wantEdges: []string{"choiceStateMachine: entryEvent"},
+		},
+		{
+			name: "single choice",
+			handler: func(ctx context.Context, sm *choiceStateMachine) {
+				sm.Value = ChooseInt(ctx, 1, 3)
+			},
+			wantEdges: []string{
+				"choiceStateMachine: entryEvent [chose 1]",
+				"choiceStateMachine: entryEvent [chose 2]",
+				"choiceStateMachine: entryEvent [chose 3]",
+			},
+		},
+		{
+			name: "nested choices",
+			handler: func(ctx context.Context, sm *choiceStateMachine) {
+				sm.Flag = Choose(ctx, true, false)
+				if sm.Flag {
+					sm.Value = ChooseInt(ctx, 1, 2)
+				}
+			},
+			wantEdges: []string{
+				"choiceStateMachine: entryEvent [chose false]",
+				"choiceStateMachine: entryEvent [chose true, 1]",
+				"choiceStateMachine: entryEvent [chose true, 2]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&choiceStateMachine{})
+			idle := newTestState("idle")
+			spec.DefineStates(idle).SetInitialState(idle)
+			OnEntry(spec, idle, tt.handler)
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			m := newSolvedTestModel(t, WithStateMachines(sm))
+
+			var got []string
+			for _, tr := range m.transitions[m.initial.id] {
+				got = append(got, tr.describe(m.worlds[tr.to].env))
+				if s := tr.step(m.worlds[tr.to].env); len(s.Choices) != len(tr.choices) {
+					t.Errorf("Step.Choices = %v, want %v", s.Choices, tr.choices)
+				}
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(tt.wantEdges, got); diff != "" {
+				t.Errorf("edges mismatch (-want +got):\n%s", diff)
+			}
+			if len(m.accessible[m.initial.id]) != len(tt.wantEdges) {
+				t.Errorf("got %d successors, want %d", len(m.accessible[m.initial.id]), len(tt.wantEdges))
+			}
+		})
+	}
+}
+
+func TestChoose_dotLabels(t *testing.T) {
+	type greetingStateMachine struct {
+		StateMachine
+		Greeting string
+	}
+
+	spec := NewStateMachineSpec(&greetingStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, sm *greetingStateMachine) {
+		sm.Greeting = Choose(ctx, `say "hi"`, `C:\`)
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	m := newSolvedTestModel(t, WithStateMachines(sm))
+	var buf bytes.Buffer
+	m.writeDot(&buf)
+	got := buf.String()
+	for _, want := range []string{
+		`[ label="greetingStateMachine: entryEvent [chose say \"hi\"]" ]`,
+		`[ label="greetingStateMachine: entryEvent [chose C:\\]" ]`,
+		`Value:say \"hi\"}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in DOT output:\n%s", want, got)
+		}
+	}
+}
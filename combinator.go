@@ -0,0 +1,183 @@
+package goat
+
+import "strings"
+
+type combinatorOp int
+
+const (
+	combinatorAnd combinatorOp = iota
+	combinatorOr
+	combinatorNot
+	combinatorImplies
+)
+
+// labelCombinator is implemented by conditions built from other conditions.
+// Their operands are registered alongside them, so each operand gets its own
+// label and the combination can be recomputed from those labels.
+type labelCombinator interface {
+	operands() []Condition
+	fromLabels(labels map[ConditionName]bool) bool
+}
+
+type compositeCondition struct {
+	op   combinatorOp
+	args []Condition
+	name ConditionName
+}
+
+func newCompositeCondition(op combinatorOp, args ...Condition) *compositeCondition {
+	c := &compositeCondition{op: op, args: args}
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i] = arg.Name().String()
+	}
+	switch op {
+	case combinatorNot:
+		c.name = ConditionName("!" + names[0])
+	case combinatorImplies:
+		c.name = ConditionName("(" + names[0] + " -> " + names[1] + ")")
+	case combinatorOr:
+		c.name = ConditionName("(" + strings.Join(names, " || ") + ")")
+	default:
+		c.name = ConditionName("(" + strings.Join(names, " && ") + ")")
+	}
+	return c
+}
+
+func (c *compositeCondition) Name() ConditionName { return c.name }
+
+func (c *compositeCondition) Evaluate(w world) bool {
+	return c.combine(func(arg Condition) bool { return arg.Evaluate(w) })
+}
+
+func (c *compositeCondition) operands() []Condition { return c.args }
+
+func (c *compositeCondition) fromLabels(labels map[ConditionName]bool) bool {
+	return c.combine(func(arg Condition) bool { return labels[arg.Name()] })
+}
+
+func (c *compositeCondition) antecedent() Condition {
+	if c.op != combinatorImplies {
+		return nil
+	}
+	return c.args[0]
+}
+
+func (c *compositeCondition) combine(value func(Condition) bool) bool {
+	switch c.op {
+	case combinatorNot:
+		return !value(c.args[0])
+	case combinatorImplies:
+		return !value(c.args[0]) || value(c.args[1])
+	case combinatorOr:
+		for _, arg := range c.args {
+			if value(arg) {
+				return true
+			}
+		}
+		return false
+	default:
+		for _, arg := range c.args {
+			if !value(arg) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// And returns a condition that holds when every condition in cs holds.
+// It is named after its operands, e.g. "(serverIdle && queueEmpty)", and
+// each operand is registered and reported on its own.
+//
+// And of a single condition returns that condition; And of none always holds
+// and is named "(&&)", so it cannot clash with a user condition.
+//
+// Example:
+//
+//	quiet := goat.And(serverIdle, queueEmpty)
+//	err := goat.Test(
+//	    goat.WithStateMachines(server),
+//	    goat.WithRules(goat.Always(goat.Implies(done, quiet))),
+//	)
+func And(cs ...Condition) Condition {
+	switch len(cs) {
+	case 0:
+		return BoolCondition("(&&)", true)
+	case 1:
+		return cs[0]
+	default:
+		return newCompositeCondition(combinatorAnd, cs...)
+	}
+}
+
+// Or returns a condition that holds when at least one condition in cs holds.
+// It is named after its operands, e.g. "(idle || draining)".
+//
+// Or of a single condition returns that condition; Or of none never holds and
+// is named "(||)".
+//
+// Example:
+//
+//	stable := goat.Or(idle, draining)
+func Or(cs ...Condition) Condition {
+	switch len(cs) {
+	case 0:
+		return BoolCondition("(||)", false)
+	case 1:
+		return cs[0]
+	default:
+		return newCompositeCondition(combinatorOr, cs...)
+	}
+}
+
+// Not returns a condition that holds when c does not, named "!c".
+//
+// Example:
+//
+//	busy := goat.Not(idle)
+func Not(c Condition) Condition {
+	return newCompositeCondition(combinatorNot, c)
+}
+
+// Implies returns a condition that holds when a does not hold or b holds,
+// named "(a -> b)". An invariant over it whose antecedent a never holds is
+// reported as vacuous.
+//
+// Example:
+//
+//	err := goat.Test(
+//	    goat.WithStateMachines(server),
+//	    goat.WithRules(goat.Always(goat.Implies(processing, hasRequest))),
+//	)
+func Implies(a, b Condition) Condition {
+	return newCompositeCondition(combinatorImplies, a, b)
+}
+
+// operandValues lists the operands of c, recursively and without
+// duplicates, with their values in labels.
+func operandValues(c Condition, labels map[ConditionName]bool) []string {
+	var lines []string
+	seen := make(map[ConditionName]bool)
+	var visit func(c Condition)
+	visit = func(c Condition) {
+		comb, ok := c.(labelCombinator)
+		if !ok {
+			return
+		}
+		for _, operand := range comb.operands() {
+			if seen[operand.Name()] {
+				continue
+			}
+			seen[operand.Name()] = true
+			value := "false"
+			if labels[operand.Name()] {
+				value = "true"
+			}
+			lines = append(lines, operand.Name().String()+" = "+value)
+			visit(operand)
+		}
+	}
+	visit(c)
+	return lines
+}
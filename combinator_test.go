@@ -0,0 +1,113 @@
+package goat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCombinators(t *testing.T) {
+	yes := BoolCondition("yes", true)
+	no := BoolCondition("no", false)
+	w := initialWorld(newTestStateMachine(newTestState("s")))
+
+	tests := []struct {
+		name     string
+		cond     Condition
+		wantName ConditionName
+		want     bool
+	}{
+		{name: "and", cond: And(yes, no), wantName: "(yes && no)", want: false},
+		{name: "and of three", cond: And(yes, yes, yes), wantName: "(yes && yes && yes)", want: true},
+		{name: "and of one", cond: And(no), wantName: "no", want: false},
+		{name: "and of none", cond: And(), wantName: "(&&)", want: true},
+		{name: "or", cond: Or(no, yes), wantName: "(no || yes)", want: true},
+		{name: "or of none", cond: Or(), wantName: "(||)", want: false},
+		{name: "not", cond: Not(no), wantName: "!no", want: true},
+		{name: "implies with false antecedent", cond: Implies(no, no), wantName: "(no -> no)", want: true},
+		{name: "implies", cond: Implies(yes, no), wantName: "(yes -> no)", want: false},
+		{name: "nested", cond: Not(And(yes, Or(no, Not(yes)))), wantName: "!(yes && (no || !yes))", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.Name(); got != tt.wantName {
+				t.Errorf("Name() = %q, want %q", got, tt.wantName)
+			}
+			if got := tt.cond.Evaluate(w); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinators_registerOperands(t *testing.T) {
+	sm, inA, inB := newTwoStateTestMachine(false)
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithRules(Always(Or(inA, Not(inB)))),
+	)
+	for _, name := range []ConditionName{"(inA || !inB)", "inA", "!inB", "inB"} {
+		if _, ok := m.conds[name]; !ok {
+			t.Errorf("expected condition %q to be registered", name)
+		}
+	}
+
+	var buf bytes.Buffer
+	m.writeInvariantViolations(&buf)
+	want := "Condition failed. Not Always (inA || !inB).\n" +
+		"Subconditions at violation:\n" +
+		"  inA = false\n" +
+		"  !inB = false\n" +
+		"  inB = true\n" +
+		"Path (length = 4):\n"
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestCombinators_stepConditions(t *testing.T) {
+	tests := []struct {
+		name string
+		cond func(moved, inA, inB Condition) Condition
+		want bool
+	}{
+		{
+			name: "moved while in A",
+			cond: func(moved, inA, _ Condition) Condition { return And(moved, inA) },
+			want: true,
+		},
+		{
+			name: "moved while in B",
+			cond: func(moved, _, inB Condition) Condition { return And(moved, inB) },
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, inA, inB := newTwoStateTestMachine(false)
+			moved := EventHandled[*transitionEvent]("moved", sm, nil)
+			m := newSolvedTestModel(t,
+				WithStateMachines(sm),
+				WithRules(Reachable(tt.cond(moved, inA, inB))),
+			)
+			if got := m.checkReachability()[0].Satisfied; got != tt.want {
+				t.Errorf("Satisfied = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImplies_vacuity(t *testing.T) {
+	sm, _, inB := newTwoStateTestMachine(false)
+	never := BoolCondition("never", false)
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithRules(Always(Implies(never, inB))),
+	)
+	warnings := m.invariantWarnings()
+	if len(warnings) != 1 || warnings[0].Rule != "Always (never -> inB)" {
+		t.Errorf("unexpected warnings: %+v", warnings)
+	}
+}
@@ -1,6 +1,10 @@
 package goat
 
-import "strings"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // ConditionName represents the identifier for a condition.
 type ConditionName string
@@ -223,3 +227,260 @@ func NewCondition3[T1, T2, T3 AbstractStateMachine](name string, sm1 T1, sm2 T2,
 		return check(m1, m2, m3)
 	}, sm1, sm2, sm3)
 }
+
+// Queues provides read access to in-flight events during condition
+// evaluation. Only events sent with SendTo are visible; the lifecycle events
+// enqueued by the framework for Goto and Halt are hidden.
+//
+// Passing a nil machine to Pending or Len refers to the queues of all
+// machines, in machine ID order.
+type Queues interface {
+	// Pending returns the events waiting in the queue of sm, oldest first.
+	Pending(sm AbstractStateMachine) []AbstractEvent
+	// Len returns the number of events waiting in the queue of sm.
+	Len(sm AbstractStateMachine) int
+	// Total returns the number of events in flight across all machines.
+	Total() int
+}
+
+type queuesImpl struct {
+	world world
+}
+
+func (q *queuesImpl) Pending(sm AbstractStateMachine) []AbstractEvent {
+	var smIDs []string
+	if sm != nil {
+		smIDs = []string{sm.id()}
+	} else {
+		for smID := range q.world.env.queue {
+			smIDs = append(smIDs, smID)
+		}
+		sort.Strings(smIDs)
+	}
+
+	var pending []AbstractEvent
+	for _, smID := range smIDs {
+		for _, event := range q.world.env.queue[smID] {
+			if !isInternalEvent(event) {
+				pending = append(pending, event)
+			}
+		}
+	}
+	return pending
+}
+
+func (q *queuesImpl) Len(sm AbstractStateMachine) int {
+	return len(q.Pending(sm))
+}
+
+func (q *queuesImpl) Total() int {
+	return q.Len(nil)
+}
+
+// PendingEvents returns the in-flight events of type T waiting for sm that
+// satisfy match.
+//
+// Parameters:
+//   - q: Queues accessor provided to the check function
+//   - sm: The recipient, or nil for every machine
+//   - match: Predicate over the event, or nil to accept every event of type T
+//
+// Returns the matching events, oldest first.
+//
+// Example:
+//
+//	requests := goat.PendingEvents(queues, server, func(e *ReservationRequestEvent) bool {
+//	    return e.RoomID == 1
+//	})
+func PendingEvents[T AbstractEvent](q Queues, sm AbstractStateMachine, match func(T) bool) []T {
+	var events []T
+	for _, event := range q.Pending(sm) {
+		typed, ok := event.(T)
+		if !ok {
+			continue
+		}
+		if match == nil || match(typed) {
+			events = append(events, typed)
+		}
+	}
+	return events
+}
+
+// HasPendingEvent reports whether an event of type T satisfying match is
+// waiting for sm.
+//
+// Parameters:
+//   - q: Queues accessor provided to the check function
+//   - sm: The recipient, or nil for every machine
+//   - match: Predicate over the event, or nil to accept every event of type T
+//
+// Returns true when at least one matching event is in flight.
+//
+// Example:
+//
+//	stale := goat.HasPendingEvent(queues, client, func(e *ReservationResultEvent) bool {
+//	    return e.RequestID < latest
+//	})
+func HasPendingEvent[T AbstractEvent](q Queues, sm AbstractStateMachine, match func(T) bool) bool {
+	return len(PendingEvents(q, sm, match)) > 0
+}
+
+// NewQueueCondition creates a condition that can inspect both machines and
+// in-flight events.
+//
+// Parameters:
+//   - name: The condition name
+//   - check: Predicate over the machines and queues of a world
+//
+// Returns a Condition that can be used with Test() (for example via WithRules(Always(...))).
+//
+// Example:
+//
+//	bounded := goat.NewQueueCondition("at most 2 outstanding requests", func(_ goat.Machines, q goat.Queues) bool {
+//	    return len(goat.PendingEvents[*ReservationRequestEvent](q, server, nil)) <= 2
+//	})
+func NewQueueCondition(name string, check func(Machines, Queues) bool) Condition {
+	return conditionFunc{name: ConditionName(name), fn: func(w world) bool {
+		return check(&machinesImpl{world: w}, &queuesImpl{world: w})
+	}}
+}
+
+// machinesOfType returns the machines of type T in w, in machine ID order.
+func machinesOfType[T AbstractStateMachine](w world) []T {
+	smIDs := make([]string, 0, len(w.env.machines))
+	for smID := range w.env.machines {
+		smIDs = append(smIDs, smID)
+	}
+	sort.Strings(smIDs)
+
+	var machines []T
+	for _, smID := range smIDs {
+		if typed, ok := w.env.machines[smID].(T); ok {
+			machines = append(machines, typed)
+		}
+	}
+	return machines
+}
+
+// ForAll creates a condition that holds when every state machine of type T
+// in the world satisfies check. It holds trivially when there is none.
+//
+// Machines are looked up by type in each world, so the condition also covers
+// instances that are not known when it is created.
+//
+// Parameters:
+//   - name: The condition name
+//   - check: Predicate applied to each machine of type T
+//
+// Returns a Condition that can be used with Test() (for example via WithRules(Always(...))).
+//
+// Example:
+//
+//	idle := goat.ForAll("all clients idle", func(c *Client) bool {
+//	    _, ok := c.currentState().(*IdleState)
+//	    return ok
+//	})
+func ForAll[T AbstractStateMachine](name string, check func(T) bool) Condition {
+	return conditionFunc{name: ConditionName(name), fn: func(w world) bool {
+		for _, sm := range machinesOfType[T](w) {
+			if !check(sm) {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+// Exists creates a condition that holds when at least one state machine of
+// type T in the world satisfies check.
+//
+// Parameters:
+//   - name: The condition name
+//   - check: Predicate applied to each machine of type T
+//
+// Returns a Condition that can be used with Test() (for example via WithRules(Always(...))).
+//
+// Example:
+//
+//	waiting := goat.Exists("some client waiting", func(c *Client) bool {
+//	    return c.Waiting
+//	})
+func Exists[T AbstractStateMachine](name string, check func(T) bool) Condition {
+	return conditionFunc{name: ConditionName(name), fn: func(w world) bool {
+		for _, sm := range machinesOfType[T](w) {
+			if check(sm) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// Count creates a condition over the number of state machines of type T in
+// the world that satisfy pred.
+//
+// Parameters:
+//   - name: The condition name
+//   - pred: Predicate selecting the machines to count
+//   - check: Predicate over the number of selected machines
+//
+// Returns a Condition that can be used with Test() (for example via WithRules(Always(...))).
+//
+// Example:
+//
+//	exclusive := goat.Count("at most one client holds room 1",
+//	    func(c *Client) bool { return c.RoomID == 1 },
+//	    func(n int) bool { return n <= 1 },
+//	)
+func Count[T AbstractStateMachine](name string, pred func(T) bool, check func(int) bool) Condition {
+	return conditionFunc{name: ConditionName(name), fn: func(w world) bool {
+		n := 0
+		for _, sm := range machinesOfType[T](w) {
+			if pred(sm) {
+				n++
+			}
+		}
+		return check(n)
+	}}
+}
+
+// stateInvariantUse records a state invariant of one machine met during the
+// search: where it applies, named after the machine ID and the state, and
+// whether the machine was ever there. Invariants of states that are never
+// reached are reported as vacuous.
+type stateInvariantUse struct {
+	where   string
+	reached bool
+}
+
+// checkStateInvariants checks the state invariants of every machine of w,
+// whether passed to WithStateMachines or spawned, and returns the names of
+// those that fail.
+func (m *model) checkStateInvariants(w world) []ConditionName {
+	smIDs := make([]string, 0, len(w.env.machines))
+	for smID := range w.env.machines {
+		smIDs = append(smIDs, smID)
+	}
+	sort.Strings(smIDs)
+
+	var failed []ConditionName
+	for _, smID := range smIDs {
+		sm := w.env.machines[smID]
+		for _, inv := range getInnerStateMachine(sm).stateInvariants {
+			where := fmt.Sprintf("%s in %s", smID, getStateName(inv.state))
+			name := ConditionName(where + ": " + inv.name)
+			in := inState(sm, inv.state)
+			if m.stateInvariants == nil {
+				m.stateInvariants = make(map[ConditionName]stateInvariantUse)
+			}
+			m.stateInvariants[name] = stateInvariantUse{
+				where:   where,
+				reached: m.stateInvariants[name].reached || in,
+			}
+			if in && !inv.check(sm) {
+				failed = append(failed, name)
+			}
+		}
+	}
+	return failed
+}
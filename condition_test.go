@@ -294,3 +294,114 @@ func TestNewCondition3(t *testing.T) {
 		}
 	})
 }
+
+func TestNewQueueCondition(t *testing.T) {
+	sm1 := newTestStateMachine(newTestState("a"))
+	sm2 := newTestStateMachine(newTestState("b"))
+	w := initialWorld(sm1, sm2)
+	w.env.queue[sm1.id()] = append(w.env.queue[sm1.id()], &testEvent{Value: 1}, &testEvent{Value: 2})
+	w.env.queue[sm2.id()] = append(w.env.queue[sm2.id()], &testEvent{Value: 3}, &genericTestEvent[string]{Payload: "x"})
+
+	tests := []struct {
+		name  string
+		check func(Machines, Queues) bool
+		want  bool
+	}{
+		{
+			name:  "length ignores lifecycle events",
+			check: func(_ Machines, q Queues) bool { return q.Len(sm1) == 2 && q.Len(sm2) == 2 },
+			want:  true,
+		},
+		{
+			name:  "total counts every machine",
+			check: func(_ Machines, q Queues) bool { return q.Total() == 4 && len(q.Pending(nil)) == 4 },
+			want:  true,
+		},
+		{
+			name: "pending events are ordered and typed",
+			check: func(_ Machines, q Queues) bool {
+				events := PendingEvents[*testEvent](q, sm1, nil)
+				return len(events) == 2 && events[0].Value == 1 && events[1].Value == 2
+			},
+			want: true,
+		},
+		{
+			name: "pending events filtered by predicate across machines",
+			check: func(_ Machines, q Queues) bool {
+				return len(PendingEvents(q, nil, func(e *testEvent) bool { return e.Value > 1 })) == 2
+			},
+			want: true,
+		},
+		{
+			name: "has pending event",
+			check: func(_ Machines, q Queues) bool {
+				return HasPendingEvent(q, sm2, func(e *testEvent) bool { return e.Value == 3 })
+			},
+			want: true,
+		},
+		{
+			name: "no matching pending event",
+			check: func(_ Machines, q Queues) bool {
+				return HasPendingEvent(q, sm1, func(e *testEvent) bool { return e.Value == 3 })
+			},
+			want: false,
+		},
+		{
+			name: "machines are accessible",
+			check: func(ms Machines, _ Queues) bool {
+				_, ok := GetMachine(ms, sm2)
+				return ok
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := NewQueueCondition("queues", tt.check)
+			if got := cond.Evaluate(w); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantifiedConditions(t *testing.T) {
+	type otherStateMachine struct{ StateMachine }
+	type unusedStateMachine struct{ StateMachine }
+	otherSpec := NewStateMachineSpec(&otherStateMachine{})
+	s := newTestState("a")
+	otherSpec.DefineStates(s).SetInitialState(s)
+	other, _ := otherSpec.NewInstance()
+
+	sm1 := newTestStateMachine(newTestState("a"))
+	sm2 := newTestStateMachine(newTestState("b"))
+	sm3 := newTestStateMachine(newTestState("a"))
+	w := initialWorld(sm1, sm2, sm3, other)
+
+	inA := func(sm *testStateMachine) bool { return sm.currentState().(*testState).Name == "a" }
+	inC := func(sm *testStateMachine) bool { return sm.currentState().(*testState).Name == "c" }
+
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{name: "for all fails when one differs", cond: ForAll("all a", inA), want: false},
+		{name: "for all holds", cond: ForAll("all test", func(*testStateMachine) bool { return true }), want: true},
+		{name: "for all holds without machines", cond: ForAll("none", func(*unusedStateMachine) bool { return false }), want: true},
+		{name: "exists holds", cond: Exists("some a", inA), want: true},
+		{name: "exists fails", cond: Exists("some c", inC), want: false},
+		{name: "exists ignores other types", cond: Exists("other", func(*otherStateMachine) bool { return true }), want: true},
+		{name: "count matches", cond: Count("two in a", inA, func(n int) bool { return n == 2 }), want: true},
+		{name: "count bound", cond: Count("at most one in a", inA, func(n int) bool { return n <= 1 }), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.Evaluate(w); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package goat
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// HandlerOption configures a handler registered with OnEvent or OnEntry.
+type HandlerOption interface {
+	applyHandler(*handlerOptions)
+}
+
+type handlerOptionFunc func(*handlerOptions)
+
+func (f handlerOptionFunc) applyHandler(o *handlerOptions) {
+	f(o)
+}
+
+type handlerOptions struct {
+	guards   []func(event AbstractEvent, sm AbstractStateMachine) (holds bool, mismatch string)
+	requires []precondition
+	ensures  []postcondition
+}
+
+// precondition and postcondition checks return a description of the type
+// mismatch when the handler they are attached to receives an event or a
+// machine of another type than their predicate expects.
+type precondition struct {
+	name  string
+	check func(event AbstractEvent, sm AbstractStateMachine) (holds bool, mismatch string)
+}
+
+type postcondition struct {
+	name  string
+	check func(before, after AbstractStateMachine) (holds bool, mismatch string)
+}
+
+func newHandlerOptions(opts []HandlerOption) handlerOptions {
+	var o handlerOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt.applyHandler(&o)
+		}
+	}
+	return o
+}
+
+// Guard restricts a handler to the events and machines for which guard
+// returns true. A handler whose guard does not hold is disabled and produces
+// no successor; when no handler for an event is enabled, the event is treated
+// as unhandled. Several guards on the same handler must all hold.
+//
+// For OnEntry handlers, use AbstractEvent as the event type. A guard that
+// panics, or that receives an event or a machine of another type than it
+// expects, aborts the step, which is reported as a violation.
+//
+// Parameters:
+//   - guard: Predicate over the event and the machine before the handler runs
+//
+// Returns a HandlerOption that can be passed to OnEvent or OnEntry.
+//
+// Example:
+//
+//	goat.OnEvent(spec, &Idle{}, acceptReservation,
+//	    goat.Guard(func(e *ReservationRequestEvent, sm *Server) bool { return !sm.Reserved[e.RoomID] }))
+//	goat.OnEvent(spec, &Idle{}, rejectReservation,
+//	    goat.Guard(func(e *ReservationRequestEvent, sm *Server) bool { return sm.Reserved[e.RoomID] }))
+func Guard[T AbstractEvent, SM AbstractStateMachine](guard func(event T, sm SM) bool) HandlerOption {
+	return handlerOptionFunc(func(o *handlerOptions) {
+		o.guards = append(o.guards, func(event AbstractEvent, sm AbstractStateMachine) (bool, string) {
+			typedEvent, mismatch := typedEvent[T](event)
+			if mismatch != "" {
+				return false, mismatch
+			}
+			typedSM, mismatch := typedMachine[SM](sm)
+			if mismatch != "" {
+				return false, mismatch
+			}
+			return guard(typedEvent, typedSM), ""
+		})
+	})
+}
+
+const (
+	failureGuardPanic    = "Guard panicked"
+	failureGuardMismatch = "Guard type mismatch"
+)
+
+// checkGuards reports whether the guards of a handler of smID allow it to
+// handle event in env. A guard that panics or does not accept the types of
+// event and the machine yields a failure instead.
+func (o handlerOptions) checkGuards(env environment, smID string, event AbstractEvent) (holds bool, failure *handlerFailure) {
+	sm, exists := env.machines[smID]
+	if !exists {
+		return true, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			f := panicFailure(failureGuardPanic, smID, event, r)
+			holds, failure = false, &f
+		}
+	}()
+	for _, guard := range o.guards {
+		ok, mismatch := guard(event, sm)
+		if mismatch != "" {
+			return false, &handlerFailure{
+				kind:    failureGuardMismatch,
+				message: mismatch,
+				details: []string{"Machine: " + smID, "Event: " + getEventName(event)},
+			}
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// enabled reports whether the guards of a handler of smID allow it to handle
+// event in env. A handler whose guards fail counts as enabled, so that the
+// event is dispatched to it and the failure reported.
+func (o handlerOptions) enabled(env environment, smID string, event AbstractEvent) bool {
+	holds, failure := o.checkGuards(env, smID, event)
+	return holds || failure != nil
+}
+
+const (
+	failurePrecondition  = "Precondition failed"
+	failurePostcondition = "Postcondition failed"
+)
+
+// Requires declares a precondition of a handler: pre must hold for the event
+// and the machine whenever the handler runs. A violation is reported with the
+// machine as it was when the handler started.
+//
+// For handlers that do not receive an event, such as OnEntry handlers, use
+// AbstractEvent as the event type. When the handler receives an event or a
+// machine of another type than pre expects, the precondition fails and the
+// report names both types.
+//
+// Parameters:
+//   - name: Name of the precondition
+//   - pre: Predicate over the event and the machine before the handler runs
+//
+// Returns a HandlerOption that can be passed to OnEvent or OnEntry.
+//
+// Example:
+//
+//	goat.OnEvent(spec, &Processing{}, handleResult,
+//	    goat.Requires("result for current request", func(e *DBUpdateResultEvent, sm *Server) bool {
+//	        return e.RequestID == sm.CurrentRequest
+//	    }))
+func Requires[T AbstractEvent, SM AbstractStateMachine](name string, pre func(event T, sm SM) bool) HandlerOption {
+	return handlerOptionFunc(func(o *handlerOptions) {
+		o.requires = append(o.requires, precondition{
+			name: name,
+			check: func(event AbstractEvent, sm AbstractStateMachine) (bool, string) {
+				typedEvent, mismatch := typedEvent[T](event)
+				if mismatch != "" {
+					return false, mismatch
+				}
+				typedSM, mismatch := typedMachine[SM](sm)
+				if mismatch != "" {
+					return false, mismatch
+				}
+				return pre(typedEvent, typedSM), ""
+			},
+		})
+	})
+}
+
+// Ensures declares a postcondition of a handler: post must hold for the
+// machine before and after every run of the handler. A violation is reported
+// with both versions of the machine. When the handler belongs to a machine of
+// another type than post expects, the postcondition fails and the report
+// names both types.
+//
+// Parameters:
+//   - name: Name of the postcondition
+//   - post: Predicate over the machine before and after the handler runs
+//
+// Returns a HandlerOption that can be passed to OnEvent or OnEntry.
+//
+// Example:
+//
+//	goat.OnEvent(spec, &Idle{}, handleRequest,
+//	    goat.Ensures("request count grows by one", func(before, after *Server) bool {
+//	        return after.Requests == before.Requests+1
+//	    }))
+func Ensures[SM AbstractStateMachine](name string, post func(before, after SM) bool) HandlerOption {
+	return handlerOptionFunc(func(o *handlerOptions) {
+		o.ensures = append(o.ensures, postcondition{
+			name: name,
+			check: func(before, after AbstractStateMachine) (bool, string) {
+				typedBefore, mismatch := typedMachine[SM](before)
+				if mismatch != "" {
+					return false, mismatch
+				}
+				typedAfter, _ := typedMachine[SM](after)
+				return post(typedBefore, typedAfter), ""
+			},
+		})
+	})
+}
+
+// run calls fn, which executes a handler of sm for event, and checks the
+// contracts around it. Broken contracts are recorded as failures of the step.
+func (o handlerOptions) run(env *environment, event AbstractEvent, sm AbstractStateMachine, fn func()) {
+	for _, pre := range o.requires {
+		if holds, mismatch := pre.check(event, sm); !holds {
+			env.recordFailure(handlerFailure{
+				kind:    failurePrecondition,
+				message: pre.name,
+				details: mismatchDetails(mismatch, "Machine before handler:", "    "+machineLine(sm)),
+			})
+		}
+	}
+	if len(o.ensures) == 0 {
+		fn()
+		return
+	}
+
+	before := cloneStateMachine(sm)
+	fn()
+	for _, post := range o.ensures {
+		if holds, mismatch := post.check(before, sm); !holds {
+			env.recordFailure(handlerFailure{
+				kind:    failurePostcondition,
+				message: post.name,
+				details: mismatchDetails(mismatch,
+					"Machine before handler:", "    "+machineLine(before),
+					"Machine after handler:", "    "+machineLine(sm),
+				),
+			})
+		}
+	}
+}
+
+// typedEvent converts event to the event type T of a handler option. It
+// returns a description of the mismatch when event is not a T.
+func typedEvent[T AbstractEvent](event AbstractEvent) (T, string) {
+	typed, ok := event.(T)
+	if !ok {
+		return typed, fmt.Sprintf("event %T is not a %s", event, reflect.TypeFor[T]())
+	}
+	return typed, ""
+}
+
+// typedMachine converts sm to the machine type SM of a handler option. It
+// returns a description of the mismatch when sm is not an SM.
+func typedMachine[SM AbstractStateMachine](sm AbstractStateMachine) (SM, string) {
+	typed, ok := sm.(SM)
+	if !ok {
+		return typed, fmt.Sprintf("machine %T is not a %s", sm, reflect.TypeFor[SM]())
+	}
+	return typed, ""
+}
+
+// mismatchDetails prepends the type mismatch that made a contract fail, if
+// any, to the details of its failure.
+func mismatchDetails(mismatch string, details ...string) []string {
+	if mismatch == "" {
+		return details
+	}
+	return append([]string{"Type mismatch: " + mismatch}, details...)
+}
+
+// machineLine describes sm the way traces list machines.
+func machineLine(sm AbstractStateMachine) string {
+	return "Name: " + getStateMachineName(sm) +
+		", Detail: " + getStateMachineDetails(sm) +
+		", State: " + describeState(sm)
+}
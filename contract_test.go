@@ -0,0 +1,221 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestHandlerContracts(t *testing.T) {
+	type counterStateMachine struct {
+		StateMachine
+		Count int
+	}
+
+	tests := []struct {
+		name  string
+		opts  []HandlerOption
+		want  string
+		lines []string
+	}{
+		{
+			name: "contracts hold",
+			opts: []HandlerOption{
+				Requires("positive value", func(e *testEvent, _ *counterStateMachine) bool { return e.Value > 0 }),
+				Ensures("count grows", func(before, after *counterStateMachine) bool { return after.Count == before.Count+1 }),
+			},
+		},
+		{
+			name: "precondition fails",
+			opts: []HandlerOption{
+				Requires("negative value", func(e *testEvent, _ *counterStateMachine) bool { return e.Value < 0 }),
+			},
+			want: "Precondition failed: negative value.\n",
+			lines: []string{
+				"Machine before handler:\n    Name: counterStateMachine, Detail: {Name:Count,Type:int,Value:0}",
+			},
+		},
+		{
+			name: "postcondition fails",
+			opts: []HandlerOption{
+				Ensures("count unchanged", func(before, after *counterStateMachine) bool { return after.Count == before.Count }),
+			},
+			want: "Postcondition failed: count unchanged.\n",
+			lines: []string{
+				"Machine before handler:\n    Name: counterStateMachine, Detail: {Name:Count,Type:int,Value:0}",
+				"Machine after handler:\n    Name: counterStateMachine, Detail: {Name:Count,Type:int,Value:1}",
+			},
+		},
+		{
+			name: "precondition event type mismatch",
+			opts: []HandlerOption{
+				Requires("generic payload", func(_ *genericTestEvent[int], _ *counterStateMachine) bool { return true }),
+			},
+			want:  "Precondition failed: generic payload.\n",
+			lines: []string{"Type mismatch: event *goat.testEvent is not a *goat.genericTestEvent[int]"},
+		},
+		{
+			name: "postcondition machine type mismatch",
+			opts: []HandlerOption{
+				Ensures("test machine", func(_, _ *testStateMachine) bool { return true }),
+			},
+			want:  "Postcondition failed: test machine.\n",
+			lines: []string{"Type mismatch: machine *goat.counterStateMachine is not a *goat.testStateMachine"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&counterStateMachine{})
+			idle := newTestState("idle")
+			spec.DefineStates(idle).SetInitialState(idle)
+			OnEntry(spec, idle, func(ctx context.Context, sm *counterStateMachine) {
+				SendTo(ctx, sm, &testEvent{Value: 1})
+			}, Requires("idle on entry", func(_ AbstractEvent, sm *counterStateMachine) bool { return sm.Count == 0 }))
+			OnEvent(spec, idle, func(_ context.Context, _ *testEvent, sm *counterStateMachine) {
+				sm.Count++
+			}, tt.opts...)
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			m := newSolvedTestModel(t, WithStateMachines(sm))
+			if m.hasInvariantViolation != (tt.want != "") {
+				t.Fatalf("hasInvariantViolation = %v, want %v", m.hasInvariantViolation, tt.want != "")
+			}
+
+			var buf bytes.Buffer
+			m.writeInvariantViolations(&buf)
+			got := buf.String()
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("unexpected header:\n%s", got)
+			}
+			for _, line := range tt.lines {
+				if !strings.Contains(got, line) {
+					t.Errorf("expected %q in report:\n%s", line, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	type guardStateMachine struct {
+		StateMachine
+		Accepted int
+		Rejected int
+	}
+
+	tests := []struct {
+		name  string
+		value int
+		want  []guardStateMachine
+	}{
+		{name: "first guard enabled", value: 1, want: []guardStateMachine{{Accepted: 1}}},
+		{name: "second guard enabled", value: 2, want: []guardStateMachine{{Rejected: 1}}},
+		{name: "no guard enabled", value: 3, want: []guardStateMachine{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&guardStateMachine{})
+			idle := newTestState("idle")
+			spec.DefineStates(idle).SetInitialState(idle)
+			OnEvent(spec, idle, func(_ context.Context, _ *testEvent, sm *guardStateMachine) {
+				sm.Accepted++
+			}, Guard(func(e *testEvent, _ *guardStateMachine) bool { return e.Value == 1 }))
+			OnEvent(spec, idle, func(_ context.Context, _ *testEvent, sm *guardStateMachine) {
+				sm.Rejected++
+			}, Guard(func(e *testEvent, _ *guardStateMachine) bool { return e.Value == 2 }))
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			env := initialWorld(sm).env
+			env.queue[sm.id()] = []AbstractEvent{&testEvent{Value: tt.value}}
+			lss, err := stepLocal(env, sm.id())
+			if err != nil {
+				t.Fatalf("stepLocal error: %v", err)
+			}
+
+			var got []guardStateMachine
+			for _, ls := range lss {
+				after := ls.env.machines[sm.id()].(*guardStateMachine)
+				got = append(got, guardStateMachine{Accepted: after.Accepted, Rejected: after.Rejected})
+				if len(ls.env.queue[sm.id()]) != 0 {
+					t.Errorf("event should be consumed, queue = %v", ls.env.queue[sm.id()])
+				}
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(guardStateMachine{}, "StateMachine")); diff != "" {
+				t.Errorf("machines mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGuard_failures(t *testing.T) {
+	type guardStateMachine struct {
+		StateMachine
+	}
+
+	tests := []struct {
+		name  string
+		entry []HandlerOption
+		event []HandlerOption
+		want  string
+	}{
+		{
+			name:  "guard panics",
+			event: []HandlerOption{Guard(func(_ *testEvent, _ *guardStateMachine) bool { panic("broken guard") })},
+			want:  "Guard panicked: broken guard.\n",
+		},
+		{
+			name:  "event type mismatch",
+			event: []HandlerOption{Guard(func(_ *genericTestEvent[int], _ *guardStateMachine) bool { return true })},
+			want:  "Guard type mismatch: event *goat.testEvent is not a *goat.genericTestEvent[int].\n",
+		},
+		{
+			name:  "machine type mismatch",
+			event: []HandlerOption{Guard(func(_ *testEvent, _ *testStateMachine) bool { return true })},
+			want:  "Guard type mismatch: machine *goat.guardStateMachine is not a *goat.testStateMachine.\n",
+		},
+		{
+			name:  "event guard on entry handler",
+			entry: []HandlerOption{Guard(func(_ *testEvent, _ *guardStateMachine) bool { return true })},
+			want:  "Guard type mismatch: event *goat.entryEvent is not a *goat.testEvent.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&guardStateMachine{})
+			idle := newTestState("idle")
+			spec.DefineStates(idle).SetInitialState(idle).SetUnhandledEventPolicy(DeferUnhandledEvents)
+			OnEntry(spec, idle, func(ctx context.Context, sm *guardStateMachine) {
+				SendTo(ctx, sm, &testEvent{Value: 1})
+			}, tt.entry...)
+			OnEvent(spec, idle, func(_ context.Context, _ *testEvent, _ *guardStateMachine) {}, tt.event...)
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			m := newSolvedTestModel(t, WithStateMachines(sm))
+			if !m.hasInvariantViolation {
+				t.Fatal("expected a violation")
+			}
+
+			var buf bytes.Buffer
+			m.writeInvariantViolations(&buf)
+			if got := buf.String(); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("unexpected header:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,536 @@
+package goat
+
+import (
+	"fmt"
+	"slices"
+)
+
+type ctlOp int
+
+const (
+	ctlProp ctlOp = iota
+	ctlNot
+	ctlAnd
+	ctlOr
+	ctlEX
+	ctlAX
+	ctlEF
+	ctlAF
+	ctlEG
+	ctlAG
+	ctlEU
+	ctlAU
+)
+
+// CTLFormula is a computation tree logic formula over conditions.
+// Formulas are built with Prop and the path quantifier helpers such as
+// AG, EF and AU, and are checked with the CTL rule.
+type CTLFormula struct {
+	op   ctlOp
+	cond Condition
+	args []*CTLFormula
+}
+
+// String returns the formula in conventional CTL notation.
+func (f *CTLFormula) String() string {
+	switch f.op {
+	case ctlProp:
+		return f.cond.Name().String()
+	case ctlNot:
+		return "!" + f.args[0].String()
+	case ctlAnd:
+		return fmt.Sprintf("(%s && %s)", f.args[0], f.args[1])
+	case ctlOr:
+		return fmt.Sprintf("(%s || %s)", f.args[0], f.args[1])
+	case ctlEU:
+		return fmt.Sprintf("E[%s U %s]", f.args[0], f.args[1])
+	case ctlAU:
+		return fmt.Sprintf("A[%s U %s]", f.args[0], f.args[1])
+	default:
+		return ctlOpNames[f.op] + " " + f.args[0].String()
+	}
+}
+
+var ctlOpNames = map[ctlOp]string{
+	ctlEX: "EX",
+	ctlAX: "AX",
+	ctlEF: "EF",
+	ctlAF: "AF",
+	ctlEG: "EG",
+	ctlAG: "AG",
+}
+
+// existential reports whether f is an existential path formula, whose
+// explanation is a witness when it holds.
+func (f *CTLFormula) existential() bool {
+	switch f.op {
+	case ctlEX, ctlEF, ctlEG, ctlEU:
+		return true
+	}
+	return false
+}
+
+func (f *CTLFormula) conditions() []Condition {
+	if f.op == ctlProp {
+		return []Condition{f.cond}
+	}
+	var conds []Condition
+	for _, arg := range f.args {
+		conds = append(conds, arg.conditions()...)
+	}
+	return conds
+}
+
+// Prop lifts a condition into a CTL formula that holds in every world
+// where the condition holds.
+//
+// Parameters:
+//   - c: Condition evaluated in each world
+//
+// Returns an atomic CTL formula.
+//
+// Example:
+//
+//	reset := goat.Prop(idle)
+//	rule := goat.CTL(goat.AG(goat.EF(reset)))
+func Prop(c Condition) *CTLFormula {
+	return &CTLFormula{op: ctlProp, cond: c}
+}
+
+// CTLNot negates a CTL formula.
+func CTLNot(f *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlNot, args: []*CTLFormula{f}}
+}
+
+// CTLAnd holds in worlds where both f and g hold.
+func CTLAnd(f, g *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlAnd, args: []*CTLFormula{f, g}}
+}
+
+// CTLOr holds in worlds where f or g holds.
+func CTLOr(f, g *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlOr, args: []*CTLFormula{f, g}}
+}
+
+// EX holds in worlds that have at least one successor where f holds.
+func EX(f *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlEX, args: []*CTLFormula{f}}
+}
+
+// AX holds in worlds whose successors all satisfy f.
+func AX(f *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlAX, args: []*CTLFormula{f}}
+}
+
+// EF holds in worlds from which some path reaches a world where f holds.
+func EF(f *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlEF, args: []*CTLFormula{f}}
+}
+
+// AF holds in worlds from which every path reaches a world where f holds.
+func AF(f *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlAF, args: []*CTLFormula{f}}
+}
+
+// EG holds in worlds from which some path satisfies f forever.
+func EG(f *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlEG, args: []*CTLFormula{f}}
+}
+
+// AG holds in worlds from which every reachable world satisfies f.
+func AG(f *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlAG, args: []*CTLFormula{f}}
+}
+
+// EU holds in worlds from which some path keeps f true until g holds.
+func EU(f, g *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlEU, args: []*CTLFormula{f, g}}
+}
+
+// AU holds in worlds from which every path keeps f true until g holds.
+func AU(f, g *CTLFormula) *CTLFormula {
+	return &CTLFormula{op: ctlAU, args: []*CTLFormula{f, g}}
+}
+
+// CTL returns a rule enforcing that f holds in the initial world.
+//
+// Worlds without successors are treated as looping on themselves, matching
+// the semantics used for the other temporal rules.
+//
+// Parameters:
+//   - f: CTL formula built from Prop and the path quantifier helpers
+//
+// Returns a Rule that can be registered with WithRules.
+//
+// Example:
+//
+//	// From every reachable world a reset is still possible.
+//	err := goat.Test(
+//		goat.WithStateMachines(server),
+//		goat.WithRules(
+//			goat.CTL(goat.AG(goat.EF(goat.Prop(reset)))),
+//		),
+//	)
+func CTL(f *CTLFormula) Rule {
+	return ruleFunc(func(o *options) {
+		for _, c := range f.conditions() {
+			registerCondition(o, c)
+		}
+		o.ctlRules = append(o.ctlRules, ctlRule{f: f})
+	})
+}
+
+type ctlRule struct {
+	f *CTLFormula
+}
+
+func (r ctlRule) name() string { return r.f.String() }
+
+// ctlTrace explains why a formula holds or fails in a world. Paths start at
+// World; children explain subformulas at the last world of the path (or of
+// the loop when one is present).
+type ctlTrace struct {
+	Formula  string      `json:"formula"`
+	World    worldID     `json:"world"`
+	Holds    bool        `json:"holds"`
+	Path     []worldID   `json:"path,omitempty"`
+	Loop     []worldID   `json:"loop,omitempty"`
+	Children []*ctlTrace `json:"children,omitempty"`
+}
+
+func (*ctlTrace) temporalEvidence() {}
+
+func (m *model) checkCTL() []temporalRuleResult {
+	results := make([]temporalRuleResult, 0, len(m.ctlRules))
+	for _, r := range m.ctlRules {
+		c := newCTLChecker(m)
+		holds := c.sat(r.f)[m.initial.id]
+		result := temporalRuleResult{Rule: r.name(), Satisfied: holds}
+		if !holds {
+			m.hasTemporalViolation = true
+		}
+		if !holds || r.f.existential() {
+			result.Evidence = c.explain(r.f, m.initial.id)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+type ctlChecker struct {
+	m     *model
+	cache map[*CTLFormula]map[worldID]bool
+	preds map[worldID][]worldID
+}
+
+func newCTLChecker(m *model) *ctlChecker {
+	preds := make(map[worldID][]worldID, len(m.worlds))
+	for w := range m.worlds {
+		for _, s := range m.successors(w) {
+			preds[s] = append(preds[s], w)
+		}
+	}
+	return &ctlChecker{
+		m:     m,
+		cache: make(map[*CTLFormula]map[worldID]bool),
+		preds: preds,
+	}
+}
+
+func (c *ctlChecker) sat(f *CTLFormula) map[worldID]bool {
+	if s, ok := c.cache[f]; ok {
+		return s
+	}
+	s := make(map[worldID]bool, len(c.m.worlds))
+	switch f.op {
+	case ctlProp:
+		name := f.cond.Name()
+		for w := range c.m.worlds {
+			s[w] = c.m.labels[w][name]
+		}
+	case ctlNot:
+		a := c.sat(f.args[0])
+		for w := range c.m.worlds {
+			s[w] = !a[w]
+		}
+	case ctlAnd, ctlOr:
+		a, b := c.sat(f.args[0]), c.sat(f.args[1])
+		for w := range c.m.worlds {
+			if f.op == ctlAnd {
+				s[w] = a[w] && b[w]
+			} else {
+				s[w] = a[w] || b[w]
+			}
+		}
+	case ctlEX:
+		a := c.sat(f.args[0])
+		for w := range c.m.worlds {
+			s[w] = slices.ContainsFunc(c.m.successors(w), func(succ worldID) bool { return a[succ] })
+		}
+	case ctlAX:
+		a := c.sat(f.args[0])
+		for w := range c.m.worlds {
+			s[w] = !slices.ContainsFunc(c.m.successors(w), func(succ worldID) bool { return !a[succ] })
+		}
+	case ctlEF:
+		s = c.untilExists(nil, c.sat(f.args[0]))
+	case ctlEU:
+		s = c.untilExists(c.sat(f.args[0]), c.sat(f.args[1]))
+	case ctlAF:
+		s = c.untilAll(nil, c.sat(f.args[0]))
+	case ctlAU:
+		s = c.untilAll(c.sat(f.args[0]), c.sat(f.args[1]))
+	case ctlEG:
+		s = c.globallyExists(c.sat(f.args[0]))
+	case ctlAG:
+		// AG f == !EF !f
+		a := c.sat(f.args[0])
+		notA := make(map[worldID]bool, len(a))
+		for w := range c.m.worlds {
+			notA[w] = !a[w]
+		}
+		ef := c.untilExists(nil, notA)
+		for w := range c.m.worlds {
+			s[w] = !ef[w]
+		}
+	}
+	c.cache[f] = s
+	return s
+}
+
+// untilExists computes E[f U g] as a least fixpoint by walking predecessors
+// backwards from g. A nil f stands for true.
+func (c *ctlChecker) untilExists(f, g map[worldID]bool) map[worldID]bool {
+	s := make(map[worldID]bool, len(c.m.worlds))
+	queue := make([]worldID, 0)
+	for w := range c.m.worlds {
+		if g[w] {
+			s[w] = true
+			queue = append(queue, w)
+		}
+	}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		for _, p := range c.preds[w] {
+			if s[p] || (f != nil && !f[p]) {
+				continue
+			}
+			s[p] = true
+			queue = append(queue, p)
+		}
+	}
+	return s
+}
+
+// untilAll computes A[f U g] as a least fixpoint. A world joins once g holds
+// there, or f holds and all of its successors have already joined. A nil f
+// stands for true.
+func (c *ctlChecker) untilAll(f, g map[worldID]bool) map[worldID]bool {
+	s := make(map[worldID]bool, len(c.m.worlds))
+	pending := make(map[worldID]int, len(c.m.worlds))
+	queue := make([]worldID, 0)
+	for w := range c.m.worlds {
+		pending[w] = len(uniqueWorldIDs(c.m.successors(w)))
+		if g[w] {
+			s[w] = true
+			queue = append(queue, w)
+		}
+	}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		for _, p := range uniqueWorldIDs(c.preds[w]) {
+			pending[p]--
+			if s[p] || pending[p] > 0 || (f != nil && !f[p]) {
+				continue
+			}
+			s[p] = true
+			queue = append(queue, p)
+		}
+	}
+	return s
+}
+
+// globallyExists computes EG f as a greatest fixpoint by repeatedly removing
+// worlds that have no successor left inside the candidate set.
+func (c *ctlChecker) globallyExists(f map[worldID]bool) map[worldID]bool {
+	s := make(map[worldID]bool, len(c.m.worlds))
+	for w := range c.m.worlds {
+		s[w] = f[w]
+	}
+	for changed := true; changed; {
+		changed = false
+		for w := range c.m.worlds {
+			if !s[w] {
+				continue
+			}
+			if !slices.ContainsFunc(c.m.successors(w), func(succ worldID) bool { return s[succ] }) {
+				s[w] = false
+				changed = true
+			}
+		}
+	}
+	return s
+}
+
+func (c *ctlChecker) explain(f *CTLFormula, w worldID) *ctlTrace {
+	holds := c.sat(f)[w]
+	t := &ctlTrace{Formula: f.String(), World: w, Holds: holds}
+
+	switch f.op {
+	case ctlProp:
+	case ctlNot:
+		t.Children = []*ctlTrace{c.explain(f.args[0], w)}
+	case ctlAnd, ctlOr:
+		// Explain the operand that decides the outcome, or both when each
+		// one contributes (both true for And, both false for Or).
+		decisive := f.op == ctlOr
+		for _, arg := range f.args {
+			if c.sat(arg)[w] == decisive {
+				t.Children = []*ctlTrace{c.explain(arg, w)}
+				return t
+			}
+		}
+		for _, arg := range f.args {
+			t.Children = append(t.Children, c.explain(arg, w))
+		}
+	case ctlEX, ctlAX:
+		a := c.sat(f.args[0])
+		// EX holding and AX failing both have a single decisive successor.
+		want := f.op == ctlEX
+		if holds == want {
+			for _, succ := range c.m.successors(w) {
+				if a[succ] == want {
+					t.Path = []worldID{w, succ}
+					t.Children = []*ctlTrace{c.explain(f.args[0], succ)}
+					break
+				}
+			}
+			return t
+		}
+		for _, succ := range uniqueWorldIDs(c.m.successors(w)) {
+			t.Children = append(t.Children, c.explain(f.args[0], succ))
+		}
+	case ctlEF, ctlEU:
+		if !holds {
+			return t
+		}
+		var through map[worldID]bool
+		if f.op == ctlEU {
+			through = c.sat(f.args[0])
+		}
+		goal := f.args[len(f.args)-1]
+		t.Path = c.shortestPath(w, through, c.sat(goal))
+		t.Children = []*ctlTrace{c.explain(goal, t.Path[len(t.Path)-1])}
+	case ctlAG:
+		if holds {
+			return t
+		}
+		a := c.sat(f.args[0])
+		notA := make(map[worldID]bool)
+		for id := range c.m.worlds {
+			notA[id] = !a[id]
+		}
+		t.Path = c.shortestPath(w, nil, notA)
+		t.Children = []*ctlTrace{c.explain(f.args[0], t.Path[len(t.Path)-1])}
+	case ctlEG:
+		if !holds {
+			return t
+		}
+		s := c.sat(f)
+		t.Path, t.Loop = c.walk(w, func(id worldID) bool { return s[id] }, nil)
+	case ctlAF, ctlAU:
+		if holds {
+			return t
+		}
+		// Follow successors that also fail the formula until either the
+		// left operand of AU breaks or the walk closes a loop.
+		s := c.sat(f)
+		var stop func(worldID) bool
+		if f.op == ctlAU {
+			left := c.sat(f.args[0])
+			stop = func(id worldID) bool { return !left[id] }
+		}
+		t.Path, t.Loop = c.walk(w, func(id worldID) bool { return !s[id] }, stop)
+		if t.Loop == nil {
+			last := t.Path[len(t.Path)-1]
+			for _, arg := range f.args {
+				t.Children = append(t.Children, c.explain(arg, last))
+			}
+		}
+	}
+	return t
+}
+
+// shortestPath finds a shortest path from start to a world in goal whose
+// intermediate worlds all belong to through. A nil through allows any world.
+func (c *ctlChecker) shortestPath(start worldID, through, goal map[worldID]bool) []worldID {
+	pre := map[worldID]worldID{start: start}
+	queue := []worldID{start}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		if goal[w] {
+			path := []worldID{w}
+			for pre[w] != w {
+				w = pre[w]
+				path = append([]worldID{w}, path...)
+			}
+			return path
+		}
+		if through != nil && !through[w] {
+			continue
+		}
+		for _, succ := range c.m.successors(w) {
+			if _, seen := pre[succ]; seen {
+				continue
+			}
+			pre[succ] = w
+			queue = append(queue, succ)
+		}
+	}
+	return []worldID{start}
+}
+
+// walk follows the first successor accepted by keep until stop holds or a
+// world repeats. It returns the path walked and, when a world repeats, the
+// loop starting at that world.
+func (c *ctlChecker) walk(start worldID, keep, stop func(worldID) bool) (path, loop []worldID) {
+	index := make(map[worldID]int)
+	w := start
+	for {
+		if i, seen := index[w]; seen {
+			return path[:i], path[i:]
+		}
+		index[w] = len(path)
+		path = append(path, w)
+		if stop != nil && stop(w) {
+			return path, nil
+		}
+		next := -1
+		succs := c.m.successors(w)
+		for i, succ := range succs {
+			if keep(succ) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return path, nil
+		}
+		w = succs[next]
+	}
+}
+
+func uniqueWorldIDs(ids []worldID) []worldID {
+	seen := make(map[worldID]bool, len(ids))
+	res := make([]worldID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
+}
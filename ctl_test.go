@@ -0,0 +1,188 @@
+package goat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		loop    bool
+		formula func(inA, inB Condition) *CTLFormula
+		want    bool
+	}{
+		{
+			name:    "AG EF reset holds on a loop",
+			loop:    true,
+			formula: func(inA, _ Condition) *CTLFormula { return AG(EF(Prop(inA))) },
+			want:    true,
+		},
+		{
+			name:    "AG EF reset fails when stuck",
+			loop:    false,
+			formula: func(inA, _ Condition) *CTLFormula { return AG(EF(Prop(inA))) },
+			want:    false,
+		},
+		{
+			name:    "AF holds when every path reaches B",
+			loop:    false,
+			formula: func(_, inB Condition) *CTLFormula { return AF(Prop(inB)) },
+			want:    true,
+		},
+		{
+			name:    "EG fails when A is eventually left",
+			loop:    true,
+			formula: func(inA, _ Condition) *CTLFormula { return EG(Prop(inA)) },
+			want:    false,
+		},
+		{
+			name:    "EG holds along a path ending in a terminal world",
+			loop:    false,
+			formula: func(inA, inB Condition) *CTLFormula { return EG(CTLOr(Prop(inA), Prop(inB))) },
+			want:    true,
+		},
+		{
+			name:    "EF EG holds once B is reached",
+			loop:    false,
+			formula: func(inA, _ Condition) *CTLFormula { return EF(EG(CTLNot(Prop(inA)))) },
+			want:    true,
+		},
+		{
+			name:    "AU holds",
+			loop:    false,
+			formula: func(inA, inB Condition) *CTLFormula { return AU(Prop(inA), Prop(inB)) },
+			want:    true,
+		},
+		{
+			name:    "EU holds",
+			loop:    false,
+			formula: func(inA, inB Condition) *CTLFormula { return EU(Prop(inA), Prop(inB)) },
+			want:    true,
+		},
+		{
+			name:    "AU fails when left operand breaks first",
+			loop:    false,
+			formula: func(inA, inB Condition) *CTLFormula { return AU(Prop(inA), CTLAnd(Prop(inA), Prop(inB))) },
+			want:    false,
+		},
+		{
+			name:    "AX and EX agree on deterministic model",
+			loop:    true,
+			formula: func(inA, _ Condition) *CTLFormula { return CTLOr(AX(Prop(inA)), CTLNot(EX(Prop(inA)))) },
+			want:    true,
+		},
+		{
+			name:    "AG of conjunction fails",
+			loop:    true,
+			formula: func(inA, inB Condition) *CTLFormula { return AG(CTLAnd(Prop(inA), CTLNot(Prop(inB)))) },
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, inA, inB := newTwoStateTestMachine(tt.loop)
+			f := tt.formula(inA, inB)
+			m := newSolvedTestModel(t,
+				WithStateMachines(sm),
+				WithRules(CTL(f)),
+			)
+			res := m.checkCTL()
+			if len(res) != 1 {
+				t.Fatalf("expected one result, got %d", len(res))
+			}
+			if res[0].Satisfied != tt.want {
+				t.Errorf("Satisfied = %v, want %v", res[0].Satisfied, tt.want)
+			}
+			if m.hasTemporalViolation == tt.want {
+				t.Errorf("hasTemporalViolation = %v, want %v", m.hasTemporalViolation, !tt.want)
+			}
+			// Violations come with a counterexample, and satisfied
+			// existential formulas with a witness.
+			if tt.want && !f.existential() {
+				if res[0].Evidence != nil {
+					t.Errorf("expected no evidence for a satisfied universal rule, got %T", res[0].Evidence)
+				}
+			} else if trace, ok := res[0].Evidence.(*ctlTrace); !ok || trace.Holds != tt.want {
+				t.Errorf("expected ctlTrace evidence with Holds = %v, got %+v", tt.want, res[0].Evidence)
+			}
+		})
+	}
+}
+
+func TestCTL_counterexample(t *testing.T) {
+	sm, inA, _ := newTwoStateTestMachine(false)
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithRules(CTL(AG(EF(Prop(inA))))),
+	)
+	res := m.checkCTL()
+
+	root := res[0].Evidence.(*ctlTrace)
+	if root.Formula != "AG EF inA" || root.Holds {
+		t.Fatalf("unexpected root: %+v", root)
+	}
+	if len(root.Path) < 2 || root.Path[0] != m.initial.id {
+		t.Fatalf("expected path from initial world, got %v", root.Path)
+	}
+	last := root.Path[len(root.Path)-1]
+	if len(root.Children) != 1 {
+		t.Fatalf("expected one child, got %d", len(root.Children))
+	}
+	child := root.Children[0]
+	if child.Formula != "EF inA" || child.Holds || child.World != last {
+		t.Errorf("unexpected child: %+v", child)
+	}
+	if m.labels[last][inA.Name()] {
+		t.Errorf("expected counterexample to end outside A")
+	}
+}
+
+func TestCTL_witness(t *testing.T) {
+	sm, _, inB := newTwoStateTestMachine(false)
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithRules(CTL(EF(Prop(inB)))),
+	)
+	res := m.checkCTL()
+
+	root, ok := res[0].Evidence.(*ctlTrace)
+	if !ok || !root.Holds {
+		t.Fatalf("expected a witness, got %+v", res[0].Evidence)
+	}
+	if len(root.Path) < 2 || root.Path[0] != m.initial.id || !m.labels[root.Path[len(root.Path)-1]][inB.Name()] {
+		t.Errorf("expected witness path from the initial world into B, got %v", root.Path)
+	}
+
+	var buf bytes.Buffer
+	m.writeWitnesses(&buf, res)
+	got := buf.String()
+	if !strings.HasPrefix(got, "\nWitness for EF inB.\n- EF inB holds\nPath (length = ") {
+		t.Errorf("unexpected witness output:\n%s", got)
+	}
+	if !strings.Contains(got, "  - inB holds\n") {
+		t.Errorf("expected witness to explain inB:\n%s", got)
+	}
+}
+
+func TestCTLFormula_String(t *testing.T) {
+	p := Prop(BoolCondition("p", true))
+	q := Prop(BoolCondition("q", true))
+	tests := []struct {
+		f    *CTLFormula
+		want string
+	}{
+		{AG(EF(p)), "AG EF p"},
+		{EU(p, q), "E[p U q]"},
+		{AU(p, CTLNot(q)), "A[p U !q]"},
+		{CTLAnd(EX(p), CTLOr(AX(q), EG(p))), "(EX p && (AX q || EG p))"},
+		{AF(q), "AF q"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,38 @@
 package goat
 
-import "context"
+import (
+	"context"
+	"maps"
+)
 
 type environment struct {
 	machines map[string]AbstractStateMachine
 	queue    map[string][]AbstractEvent
+	ghosts   map[string]ghostValue
+	// unhandledPolicy is the model-wide policy for unhandled events, applied
+	// to the machines created with Spawn.
+	unhandledPolicy UnhandledEventPolicy
+	// step records what the handlers did during the step that produced this
+	// environment. It is moved onto the transition by stepGlobal and never
+	// becomes part of a world.
+	step *stepRecord
+}
+
+type stepRecord struct {
+	sent     []sentEvent
+	failures []handlerFailure
+	// aborted is set when a handler panicked, leaving the environment in an
+	// unusable state.
+	aborted bool
+	// script prescribes the alternatives taken by Choose during this run of
+	// the handler; choices records every choice made.
+	script  []int
+	choices []choiceRecord
+}
+
+type sentEvent struct {
+	to    string
+	event AbstractEvent
 }
 
 type (
@@ -53,27 +81,73 @@ func (e *environment) clone() environment {
 	}
 
 	ec := environment{
-		machines: machines,
-		queue:    queue,
+		machines:        machines,
+		queue:           queue,
+		ghosts:          maps.Clone(e.ghosts),
+		unhandledPolicy: e.unhandledPolicy,
 	}
 	return ec
 }
 
+// reaches reports whether target is one of the machines of e. A machine
+// that terminated is not, even when Spawn gave its ID to a newer machine.
+func (e *environment) reaches(target AbstractStateMachine) bool {
+	current, exists := e.machines[target.id()]
+	return exists && getInnerStateMachine(current).incarnation == getInnerStateMachine(target).incarnation
+}
+
+// enqueueEvent appends event to the queue of target. Events for machines
+// that terminated are dropped.
 func (e *environment) enqueueEvent(target AbstractStateMachine, event AbstractEvent) {
+	if !e.reaches(target) {
+		return
+	}
 	e.queue[target.id()] = append(e.queue[target.id()], event)
 }
 
+func (e *environment) recordSent(target AbstractStateMachine, event AbstractEvent) {
+	if e.step == nil {
+		e.step = &stepRecord{}
+	}
+	to := target.id()
+	if !e.reaches(target) {
+		// The event was dropped and has no recipient.
+		to = ""
+	}
+	e.step.sent = append(e.step.sent, sentEvent{to: to, event: event})
+}
+
+func (e *environment) recordFailure(f handlerFailure) {
+	if e.step == nil {
+		e.step = &stepRecord{}
+	}
+	e.step.failures = append(e.step.failures, f)
+}
+
+// nextEvent returns the index of the event smID takes next from its queue:
+// the first one it does not defer in its current state.
+func (e *environment) nextEvent(smID string) (int, bool) {
+	for i, event := range e.queue[smID] {
+		if !isDeferred(*e, smID, event) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 func (e *environment) dequeueEvent(smID string) (AbstractEvent, bool) {
-	events, ok := e.queue[smID]
+	i, ok := e.nextEvent(smID)
 	if !ok {
 		return nil, false
 	}
-	if len(events) == 0 {
-		return nil, false
-	}
 
-	event := events[0]
-	e.queue[smID] = events[1:]
+	events := e.queue[smID]
+	event := events[i]
+	if i == 0 {
+		e.queue[smID] = events[1:]
+	} else {
+		e.queue[smID] = append(events[:i:i], events[i+1:]...)
+	}
 	return event, true
 }
 
@@ -103,6 +177,7 @@ func SendTo(ctx context.Context, target AbstractStateMachine, event AbstractEven
 	}
 
 	env.enqueueEvent(target, event)
+	env.recordSent(target, event)
 }
 
 // Goto triggers a state transition for the current state machine.
@@ -122,9 +197,26 @@ func SendTo(ctx context.Context, target AbstractStateMachine, event AbstractEven
 func Goto(ctx context.Context, state AbstractState) {
 	env := getEnvFromContext(ctx)
 	sm := getSMFromContext(ctx)
-	env.enqueueEvent(sm, &exitEvent{})
-	env.enqueueEvent(sm, &transitionEvent{To: state})
-	env.enqueueEvent(sm, &entryEvent{})
+	innerSM := getInnerStateMachine(sm)
+	if i := innerSM.regionOf(state); i >= 0 {
+		gotoRegion(env, sm, i, state)
+		return
+	}
+	if !innerSM.isHierarchical() {
+		env.enqueueEvent(sm, &exitEvent{})
+		env.enqueueEvent(sm, &transitionEvent{To: state})
+		env.enqueueEvent(sm, &entryEvent{})
+		return
+	}
+
+	exits, entries := innerSM.transitionPath(sm.currentState(), state)
+	for _, s := range exits {
+		env.enqueueEvent(sm, &exitStateEvent{State: s})
+	}
+	env.enqueueEvent(sm, &transitionEvent{To: innerSM.leafOf(state)})
+	for _, s := range entries {
+		env.enqueueEvent(sm, &enterStateEvent{State: s})
+	}
 }
 
 // Halt stops the execution of a specific state machine permanently.
@@ -116,6 +116,17 @@ type haltEvent struct {
 	UnTypedEvent
 }
 
+// isInternalEvent reports whether e is one of the lifecycle events that the
+// framework enqueues itself for Goto, Halt and machine start-up.
+func isInternalEvent(e AbstractEvent) bool {
+	switch e.(type) {
+	case *entryEvent, *exitEvent, *transitionEvent, *haltEvent, *enterStateEvent, *exitStateEvent, *regionTransitionEvent:
+		return true
+	default:
+		return false
+	}
+}
+
 func newEventPrototype[T AbstractEvent]() AbstractEvent {
 	var zero T
 	eventType := reflect.TypeOf(zero)
@@ -124,7 +135,7 @@ func newEventPrototype[T AbstractEvent]() AbstractEvent {
 	}
 
 	if eventType.Kind() == reflect.Interface {
-		panic(fmt.Sprintf("cannot use interface type %s as event type parameter; use a concrete event type instead", eventType))
+		return &eventFamily{iface: eventType}
 	}
 
 	if eventType.Kind() == reflect.Pointer {
@@ -314,4 +314,14 @@ func TestNewEventPrototype(t *testing.T) {
 			t.Errorf("expected prototype to be genericTestEvent[int], got %T", prototype)
 		}
 	})
+
+	t.Run("interface types match implementing events", func(t *testing.T) {
+		prototype := newEventPrototype[testErrorEvent]()
+		if !matchesEvent(prototype, &timeoutEvent{}) {
+			t.Error("expected prototype to match timeoutEvent")
+		}
+		if matchesEvent(prototype, &testEvent{}) {
+			t.Error("expected prototype not to match testEvent")
+		}
+	})
 }
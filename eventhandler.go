@@ -26,6 +26,22 @@ type TransitionHandler[SM AbstractStateMachine] func(ctx context.Context, toStat
 // It is called when a state machine is about to stop permanently.
 type HaltHandler[SM AbstractStateMachine] func(ctx context.Context, sm SM)
 
+// EventHandlerE is an EventHandler that can fail. A non-nil error is
+// reported as a violation of the step and the step leads to no world.
+type EventHandlerE[T AbstractEvent, SM AbstractStateMachine] func(ctx context.Context, event T, sm SM) error
+
+// EntryHandlerE is an EntryHandler that can fail.
+type EntryHandlerE[SM AbstractStateMachine] func(ctx context.Context, sm SM) error
+
+// ExitHandlerE is an ExitHandler that can fail.
+type ExitHandlerE[SM AbstractStateMachine] func(ctx context.Context, sm SM) error
+
+// TransitionHandlerE is a TransitionHandler that can fail.
+type TransitionHandlerE[SM AbstractStateMachine] func(ctx context.Context, toState AbstractState, sm SM) error
+
+// HaltHandlerE is a HaltHandler that can fail.
+type HaltHandlerE[SM AbstractStateMachine] func(ctx context.Context, sm SM) error
+
 type handler interface {
 	handle(env environment, smID string, event AbstractEvent) ([]localState, error)
 }
@@ -34,10 +50,15 @@ type handler interface {
 // to a specific event when in a particular state. This is the primary way to
 // specify the behavior and reactions of your state machine to events.
 //
+// When the event type is an interface, the handler handles every event that
+// implements it, unless a handler for the concrete event type in the same
+// state handles the event first.
+//
 // Parameters:
 //   - spec: The state machine specification to register the handler with
 //   - state: The state in which this handler should be active
 //   - fn: The function to call when the event occurs
+//   - opts: Optional handler options such as Guard, Requires and Ensures
 //
 // Example:
 //
@@ -49,19 +70,23 @@ func OnEvent[T AbstractEvent, SM AbstractStateMachine](
 	spec *StateMachineSpec[SM],
 	state AbstractState,
 	fn EventHandler[T, SM],
+	opts ...HandlerOption,
 ) {
 	event := newEventPrototype[T]()
-	builder := func(smID string) handler {
+	spec.handlerBuilders[state] = append(spec.handlerBuilders[state], handlerBuilderInfo{
+		event:   event,
+		builder: eventHandlerBuilder(event, fn, newHandlerOptions(opts)),
+	})
+}
+
+func eventHandlerBuilder[T AbstractEvent, SM AbstractStateMachine](event AbstractEvent, fn EventHandler[T, SM], hopts handlerOptions) handlerBuilder {
+	return func(smID string) handler {
 		return &eventHandlers{
-			fs:    []eventHandler{handleEvent[T, SM](smID, fn)},
+			fs:    []eventHandler{handleEvent[T, SM](smID, fn, hopts)},
 			event: event,
+			opts:  hopts,
 		}
 	}
-
-	spec.handlerBuilders[state] = append(spec.handlerBuilders[state], handlerBuilderInfo{
-		event:   event,
-		builder: builder,
-	})
 }
 
 // OnEntry registers an entry handler that defines what actions the state machine
@@ -72,6 +97,7 @@ func OnEvent[T AbstractEvent, SM AbstractStateMachine](
 //   - spec: The state machine specification to register the handler with
 //   - state: The state for which to register the entry handler
 //   - fn: The function to call when entering the state
+//   - opts: Optional handler options such as Guard, Requires and Ensures
 //
 // Example:
 //
@@ -83,10 +109,13 @@ func OnEntry[SM AbstractStateMachine](
 	spec *StateMachineSpec[SM],
 	state AbstractState,
 	fn EntryHandler[SM],
+	opts ...HandlerOption,
 ) {
+	hopts := newHandlerOptions(opts)
 	builder := func(smID string) handler {
 		return &entryHandlers{
-			fs: []entryHandler{handleEntry[SM](smID, fn)},
+			fs:   []entryHandler{handleEntry[SM](smID, fn, hopts)},
+			opts: hopts,
 		}
 	}
 
@@ -192,7 +221,104 @@ func OnHalt[SM AbstractStateMachine](
 	})
 }
 
-func handleEvent[T AbstractEvent, SM AbstractStateMachine](smID string, fn EventHandler[T, SM]) eventHandler {
+// OnEventE registers an event handler that returns an error. A non-nil error
+// is reported as a violation with the path to the failing step, instead of
+// requiring the handler to panic or store the error in the machine.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - state: The state in which this handler should be active
+//   - fn: The function to call when the event occurs
+//   - opts: Optional handler options such as Guard, Requires and Ensures
+//
+// Example:
+//
+//	goat.OnEventE(spec, &Processing{}, func(ctx context.Context, e *DBUpdateResultEvent, sm *Server) error {
+//	    if e.RequestID != sm.CurrentRequest {
+//	        return fmt.Errorf("result for unknown request %d", e.RequestID)
+//	    }
+//	    goat.Goto(ctx, &Idle{})
+//	    return nil
+//	})
+func OnEventE[T AbstractEvent, SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	state AbstractState,
+	fn EventHandlerE[T, SM],
+	opts ...HandlerOption,
+) {
+	OnEvent(spec, state, func(ctx context.Context, event T, sm SM) {
+		failOnError(ctx, fn(ctx, event, sm))
+	}, opts...)
+}
+
+// OnEntryE registers an entry handler that returns an error. See OnEventE.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - state: The state for which to register the entry handler
+//   - fn: The function to call when entering the state
+//   - opts: Optional handler options such as Guard, Requires and Ensures
+func OnEntryE[SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	state AbstractState,
+	fn EntryHandlerE[SM],
+	opts ...HandlerOption,
+) {
+	OnEntry(spec, state, func(ctx context.Context, sm SM) {
+		failOnError(ctx, fn(ctx, sm))
+	}, opts...)
+}
+
+// OnExitE registers an exit handler that returns an error. See OnEventE.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - state: The state for which to register the exit handler
+//   - fn: The function to call when exiting the state
+func OnExitE[SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	state AbstractState,
+	fn ExitHandlerE[SM],
+) {
+	OnExit(spec, state, func(ctx context.Context, sm SM) {
+		failOnError(ctx, fn(ctx, sm))
+	})
+}
+
+// OnTransitionE registers a transition handler that returns an error. See
+// OnEventE.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - state: The source state for which to register the transition handler
+//   - fn: The function to call during transition from this state
+func OnTransitionE[SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	state AbstractState,
+	fn TransitionHandlerE[SM],
+) {
+	OnTransition(spec, state, func(ctx context.Context, toState AbstractState, sm SM) {
+		failOnError(ctx, fn(ctx, toState, sm))
+	})
+}
+
+// OnHaltE registers a halt handler that returns an error. See OnEventE.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - state: The state for which to register the halt handler
+//   - fn: The function to call when the state machine halts
+func OnHaltE[SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	state AbstractState,
+	fn HaltHandlerE[SM],
+) {
+	OnHalt(spec, state, func(ctx context.Context, sm SM) {
+		failOnError(ctx, fn(ctx, sm))
+	})
+}
+
+func handleEvent[T AbstractEvent, SM AbstractStateMachine](smID string, fn EventHandler[T, SM], opts handlerOptions) eventHandler {
 	return func(event AbstractEvent, env *environment) {
 		typedEvent := event.(T)
 
@@ -204,11 +330,11 @@ func handleEvent[T AbstractEvent, SM AbstractStateMachine](smID string, fn Event
 		sm := machine.(SM)
 		ctx := withEnvAndSM(env, sm)
 
-		fn(ctx, typedEvent, sm)
+		opts.run(env, event, sm, func() { fn(ctx, typedEvent, sm) })
 	}
 }
 
-func handleEntry[SM AbstractStateMachine](smID string, fn EntryHandler[SM]) entryHandler {
+func handleEntry[SM AbstractStateMachine](smID string, fn EntryHandler[SM], opts handlerOptions) entryHandler {
 	return func(env *environment) {
 		machine, exists := env.machines[smID]
 		if !exists {
@@ -218,7 +344,7 @@ func handleEntry[SM AbstractStateMachine](smID string, fn EntryHandler[SM]) entr
 		sm := machine.(SM)
 		ctx := withEnvAndSM(env, sm)
 
-		fn(ctx, sm)
+		opts.run(env, &entryEvent{}, sm, func() { fn(ctx, sm) })
 	}
 }
 
@@ -276,15 +402,23 @@ type transitionHandler func(toState AbstractState, env *environment)
 type haltHandler func(env *environment)
 
 type entryHandlers struct {
-	fs []entryHandler
+	fs   []entryHandler
+	opts handlerOptions
 }
 
-func (h *entryHandlers) handle(env environment, _ string, _ AbstractEvent) ([]localState, error) {
+func (h *entryHandlers) handle(env environment, smID string, event AbstractEvent) ([]localState, error) {
+	holds, failure := h.opts.checkGuards(env, smID, event)
+	if failure != nil {
+		return abortedStep(env, *failure), nil
+	}
+	if !holds {
+		return nil, nil
+	}
 	lss := make([]localState, 0)
 	for _, f := range h.fs {
-		ec := env.clone()
-		f(&ec)
-		lss = append(lss, localState{env: ec})
+		lss = append(lss, forEachChoice(env, func(ec *environment) {
+			ec.runHandler(smID, event, func() { f(ec) })
+		})...)
 	}
 	return lss, nil
 }
@@ -293,12 +427,12 @@ type exitHandlers struct {
 	fs []exitHandler
 }
 
-func (h *exitHandlers) handle(env environment, _ string, _ AbstractEvent) ([]localState, error) {
+func (h *exitHandlers) handle(env environment, smID string, event AbstractEvent) ([]localState, error) {
 	lss := make([]localState, 0)
 	for _, f := range h.fs {
-		ec := env.clone()
-		f(&ec)
-		lss = append(lss, localState{env: ec})
+		lss = append(lss, forEachChoice(env, func(ec *environment) {
+			ec.runHandler(smID, event, func() { f(ec) })
+		})...)
 	}
 	return lss, nil
 }
@@ -306,21 +440,33 @@ func (h *exitHandlers) handle(env environment, _ string, _ AbstractEvent) ([]loc
 type eventHandlers struct {
 	fs    []eventHandler
 	event AbstractEvent
+	opts  handlerOptions
 }
 
-func (h *eventHandlers) handle(env environment, _ string, event AbstractEvent) ([]localState, error) {
-	if !sameEvent(h.event, event) {
+func (h *eventHandlers) handle(env environment, smID string, event AbstractEvent) ([]localState, error) {
+	if !matchesEvent(h.event, event) {
+		return nil, nil
+	}
+	holds, failure := h.opts.checkGuards(env, smID, event)
+	if failure != nil {
+		return abortedStep(env, *failure), nil
+	}
+	if !holds {
 		return nil, nil
 	}
 	lss := make([]localState, 0)
 	for _, f := range h.fs {
-		ec := env.clone()
-		f(event, &ec)
-		lss = append(lss, localState{env: ec})
+		lss = append(lss, forEachChoice(env, func(ec *environment) {
+			ec.runHandler(smID, event, func() { f(event, ec) })
+		})...)
 	}
 	return lss, nil
 }
 
+func (h *eventHandlers) enabled(env environment, smID string, event AbstractEvent) bool {
+	return h.opts.enabled(env, smID, event)
+}
+
 type transitionHandlers struct {
 	fs []transitionHandler
 }
@@ -331,11 +477,11 @@ func (h *transitionHandlers) handle(env environment, smID string, event Abstract
 	}
 	lss := make([]localState, 0)
 	for _, f := range h.fs {
-		ec := env.clone()
-		sm := ec.machines[smID]
-		f(event.(*transitionEvent).To, &ec)
-		sm.setCurrentState(event.(*transitionEvent).To)
-		lss = append(lss, localState{env: ec})
+		lss = append(lss, forEachChoice(env, func(ec *environment) {
+			sm := ec.machines[smID]
+			ec.runHandler(smID, event, func() { f(event.(*transitionEvent).To, ec) })
+			sm.setCurrentState(event.(*transitionEvent).To)
+		})...)
 	}
 	return lss, nil
 }
@@ -350,12 +496,12 @@ func (h *haltHandlers) handle(env environment, smID string, event AbstractEvent)
 	}
 	lss := make([]localState, 0)
 	for _, f := range h.fs {
-		ec := env.clone()
-		sm := ec.machines[smID]
-		f(&ec)
-		innerSm := getInnerStateMachine(sm)
-		innerSm.halted = true
-		lss = append(lss, localState{env: ec})
+		lss = append(lss, forEachChoice(env, func(ec *environment) {
+			sm := ec.machines[smID]
+			ec.runHandler(smID, event, func() { f(ec) })
+			innerSm := getInnerStateMachine(sm)
+			innerSm.halted = true
+		})...)
 	}
 	return lss, nil
 }
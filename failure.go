@@ -0,0 +1,118 @@
+package goat
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// handlerFailure is a problem detected while a handler was running, such as
+// a failed assertion. It marks the step taking the handler as a violation.
+type handlerFailure struct {
+	kind    string
+	message string
+	// details are extra lines printed after the path, e.g. the machine
+	// before and after the handler.
+	details []string
+}
+
+func (f handlerFailure) title() string {
+	if f.message == "" {
+		return f.kind
+	}
+	return f.kind + ": " + f.message
+}
+
+const failureAssertion = "Assertion failed"
+
+// Assert checks a condition from within a handler. When ok is false, the step
+// taking the handler is reported as a violation together with msg and the
+// path leading to it. The handler itself keeps running.
+//
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - ok: The condition that must hold
+//   - msg: Message describing the violation
+//
+// Example:
+//
+//	goat.OnEvent(spec, &Idle{}, func(ctx context.Context, e *DBUpdateResultEvent, sm *Server) {
+//	    goat.Assert(ctx, false, "DB update result must only arrive while processing")
+//	})
+func Assert(ctx context.Context, ok bool, msg string) {
+	if ok {
+		return
+	}
+	env := getEnvFromContext(ctx)
+	env.recordFailure(handlerFailure{kind: failureAssertion, message: msg})
+}
+
+// recordFailures reports the handler failures of tr, which leaves from.
+func (m *model) recordFailures(from world, tr transition) {
+	for _, f := range tr.failures {
+		m.hasInvariantViolation = true
+		m.stepViolations = append(m.stepViolations, stepViolation{from: from.id, tr: tr, failure: &f})
+	}
+}
+
+const failurePanic = "Handler panicked"
+
+// runHandler runs f, a handler of smID for event, and turns a panic into a
+// failure of the step. The step is then aborted: it is reported but leads
+// to no world.
+func (e *environment) runHandler(smID string, event AbstractEvent, f func()) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		e.recordFailure(panicFailure(failurePanic, smID, event, r))
+		e.step.aborted = true
+	}()
+	f()
+}
+
+// panicFailure describes r, recovered from code run for smID and event, as a
+// failure of the given kind. It must be called from the deferred function
+// that recovered r, so that the stack points at the panic.
+func panicFailure(kind, smID string, event AbstractEvent, r any) handlerFailure {
+	details := []string{
+		"Machine: " + smID,
+		"Event: " + getEventName(event),
+		"Stack:",
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(debug.Stack()), "\n"), "\n") {
+		details = append(details, "    "+line)
+	}
+	return handlerFailure{kind: kind, message: fmt.Sprint(r), details: details}
+}
+
+// abortedStep returns the local state of a step aborted by f.
+func abortedStep(env environment, f handlerFailure) []localState {
+	ec := env.clone()
+	ec.recordFailure(f)
+	ec.step.aborted = true
+	return []localState{{env: ec}}
+}
+
+const failureError = "Handler returned error"
+
+// failOnError records err, returned by the handler running with ctx, as a
+// failure of the step and aborts the step.
+func failOnError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	env := getEnvFromContext(ctx)
+	sm := getSMFromContext(ctx)
+	env.recordFailure(handlerFailure{
+		kind:    failureError,
+		message: err.Error(),
+		details: []string{"Machine: " + sm.id()},
+	})
+	env.step.aborted = true
+}
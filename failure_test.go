@@ -0,0 +1,202 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAssert(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int
+		violated bool
+	}{
+		{name: "assertion holds", value: 1, violated: false},
+		{name: "assertion fails", value: 2, violated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *testStateMachine
+			clientSpec := NewStateMachineSpec(&testStateMachine{})
+			idle := newTestState("idle")
+			clientSpec.DefineStates(idle).SetInitialState(idle)
+			OnEntry(clientSpec, idle, func(ctx context.Context, _ *testStateMachine) {
+				SendTo(ctx, server, &testEvent{Value: tt.value})
+			})
+
+			serverSpec := NewStateMachineSpec(&testStateMachine{})
+			ready := newTestState("ready")
+			serverSpec.DefineStates(ready).SetInitialState(ready)
+			OnEvent(serverSpec, ready, func(ctx context.Context, e *testEvent, _ *testStateMachine) {
+				Assert(ctx, e.Value == 1, "unexpected value")
+			})
+
+			client, err := clientSpec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+			if server, err = serverSpec.NewInstance(); err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			m := newSolvedTestModel(t, WithStateMachines(client, server))
+			if m.hasInvariantViolation != tt.violated {
+				t.Fatalf("hasInvariantViolation = %v, want %v", m.hasInvariantViolation, tt.violated)
+			}
+			if !tt.violated {
+				return
+			}
+
+			var buf bytes.Buffer
+			m.writeInvariantViolations(&buf)
+			got := buf.String()
+			if !strings.HasPrefix(got, "Assertion failed: unexpected value.\nPath (length = ") {
+				t.Errorf("unexpected header:\n%s", got)
+			}
+			if !strings.Contains(got, "<-- violation here (testStateMachine: testEvent)\n") {
+				t.Errorf("expected failing step to be annotated:\n%s", got)
+			}
+			if n := strings.Count(got, "Assertion failed"); n != 1 {
+				t.Errorf("expected a single report, got %d:\n%s", n, got)
+			}
+		})
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	type mapStateMachine struct {
+		StateMachine
+		Seen map[int]bool
+	}
+
+	spec := NewStateMachineSpec(&mapStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, sm *mapStateMachine) {
+		SendTo(ctx, sm, &testEvent{Value: 1})
+	})
+	OnEvent(spec, idle, func(_ context.Context, e *testEvent, sm *mapStateMachine) {
+		sm.Seen[e.Value] = true
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	m := newSolvedTestModel(t, WithStateMachines(sm))
+	if !m.hasInvariantViolation {
+		t.Fatal("expected the panic to be reported as a violation")
+	}
+	if len(m.worlds) != 2 {
+		t.Errorf("explored %d worlds, want 2", len(m.worlds))
+	}
+
+	var buf bytes.Buffer
+	m.writeInvariantViolations(&buf)
+	got := buf.String()
+	for _, want := range []string{
+		"Handler panicked: assignment to entry in nil map.\nPath (length = 2):\n",
+		"  [1] <-- failure in next step (mapStateMachine: testEvent)\n",
+		"Machine: " + sm.id() + "\nEvent: testEvent\nStack:\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in report:\n%s", want, got)
+		}
+	}
+}
+
+func TestHandlerPanic_temporalRules(t *testing.T) {
+	type mapStateMachine struct {
+		StateMachine
+		Seen map[int]bool
+	}
+
+	spec := NewStateMachineSpec(&mapStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, sm *mapStateMachine) {
+		SendTo(ctx, sm, &testEvent{Value: 1})
+	})
+	OnEvent(spec, idle, func(_ context.Context, e *testEvent, sm *mapStateMachine) {
+		sm.Seen[e.Value] = true
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+	seen := NewCondition("seen", sm, func(sm *mapStateMachine) bool { return sm.Seen[1] })
+
+	// The world whose only step panics must not be taken for a terminal
+	// world that never sees the event.
+	m := newSolvedTestModel(t, WithStateMachines(sm), WithRules(AlwaysEventually(seen)))
+	if res := m.checkTemporalRules(); len(res) != 0 {
+		t.Errorf("expected temporal rules to be skipped, got %+v", res)
+	}
+	if m.hasTemporalViolation {
+		t.Error("unexpected temporal violation")
+	}
+	warnings := m.collectWarnings(nil)
+	if len(warnings) != 1 || warnings[0].Rule != "Temporal rules" {
+		t.Errorf("unexpected warnings: %+v", warnings)
+	}
+}
+
+func TestOnEventE(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int
+		violated bool
+	}{
+		{name: "no error", value: 1, violated: false},
+		{name: "error returned", value: 2, violated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&testStateMachine{})
+			idle := newTestState("idle")
+			done := newTestState("done")
+			spec.DefineStates(idle, done).SetInitialState(idle)
+			OnEntryE(spec, idle, func(ctx context.Context, sm *testStateMachine) error {
+				SendTo(ctx, sm, &testEvent{Value: tt.value})
+				return nil
+			})
+			OnEventE(spec, idle, func(ctx context.Context, e *testEvent, _ *testStateMachine) error {
+				if e.Value != 1 {
+					return fmt.Errorf("unexpected value %d", e.Value)
+				}
+				Goto(ctx, done)
+				return nil
+			})
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			m := newSolvedTestModel(t, WithStateMachines(sm))
+			if m.hasInvariantViolation != tt.violated {
+				t.Fatalf("hasInvariantViolation = %v, want %v", m.hasInvariantViolation, tt.violated)
+			}
+			if !tt.violated {
+				return
+			}
+
+			var buf bytes.Buffer
+			m.writeInvariantViolations(&buf)
+			got := buf.String()
+			for _, want := range []string{
+				"Handler returned error: unexpected value 2.\nPath (length = 2):\n",
+				"  [1] <-- failure in next step (testStateMachine: testEvent)\n",
+				"Machine: " + sm.id() + "\n",
+			} {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in report:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
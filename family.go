@@ -0,0 +1,87 @@
+package goat
+
+import "reflect"
+
+// eventFamily is the event handlers are registered for when their event
+// type parameter is an interface. It matches every event implementing the
+// interface, except the internal ones.
+//
+// Handlers for an event family come after those for the concrete event type
+// in the same state and before those registered with OnAnyEvent.
+//
+// Example:
+//
+//	type ErrorEvent interface {
+//	    goat.AbstractEvent
+//	    Code() int
+//	}
+//	goat.OnEvent(spec, &Running{}, func(ctx context.Context, e ErrorEvent, sm *Server) {
+//	    sm.LastError = e.Code()
+//	})
+type eventFamily struct {
+	UnTypedEvent
+	iface reflect.Type
+}
+
+// OnEventMatching registers an event handler for the events of type T whose
+// exported fields equal the non-zero exported fields of pattern. Fields left
+// at their zero value in pattern match any value. Handlers whose pattern does
+// not match are disabled like handlers whose Guard does not hold.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - state: The state in which this handler should be active
+//   - pattern: The event whose non-zero fields the handled events must have
+//   - fn: The function to call when a matching event occurs
+//   - opts: Optional handler options such as Guard, Requires and Ensures
+//
+// Example:
+//
+//	goat.OnEventMatching(spec, &Idle{}, &ReservationRequestEvent{RoomID: 1},
+//	    func(ctx context.Context, e *ReservationRequestEvent, sm *Server) {
+//	        sm.Reserved[1] = true
+//	    })
+func OnEventMatching[T AbstractEvent, SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	state AbstractState,
+	pattern T,
+	fn EventHandler[T, SM],
+	opts ...HandlerOption,
+) {
+	match := handlerOptionFunc(func(o *handlerOptions) {
+		o.guards = append(o.guards, func(event AbstractEvent, _ AbstractStateMachine) (bool, string) {
+			return matchesPattern(pattern, event), ""
+		})
+	})
+	OnEvent(spec, state, fn, append([]HandlerOption{match}, opts...)...)
+}
+
+// matchesPattern reports whether the exported fields of event equal the
+// non-zero exported fields of pattern. Events of another type never match.
+func matchesPattern(pattern, event AbstractEvent) bool {
+	pv, ev := reflect.ValueOf(pattern), reflect.ValueOf(event)
+	if pv.Type() != ev.Type() {
+		return false
+	}
+	if pv.Kind() == reflect.Ptr {
+		if pv.IsNil() || ev.IsNil() {
+			return pv.IsNil()
+		}
+		pv, ev = pv.Elem(), ev.Elem()
+	}
+	if pv.Kind() != reflect.Struct {
+		return true
+	}
+
+	t := pv.Type()
+	for i := 0; i < pv.NumField(); i++ {
+		name := t.Field(i).Name
+		if name == "Event" || name == "UnTypedEvent" || !t.Field(i).IsExported() {
+			continue
+		}
+		if field := pv.Field(i); !field.IsZero() && !reflect.DeepEqual(field.Interface(), ev.Field(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package goat
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testErrorEvent interface {
+	AbstractEvent
+	Code() int
+}
+
+type timeoutEvent struct {
+	UnTypedEvent
+}
+
+func (*timeoutEvent) Code() int { return 408 }
+
+type refusedEvent struct {
+	UnTypedEvent
+}
+
+func (*refusedEvent) Code() int { return 503 }
+
+func TestOnEvent_eventFamily(t *testing.T) {
+	type familyStateMachine struct {
+		StateMachine
+		HandledBy []string
+	}
+
+	tests := []struct {
+		name  string
+		event AbstractEvent
+		want  []string
+	}{
+		{name: "concrete handler first", event: &timeoutEvent{}, want: []string{"timeout"}},
+		{name: "family handler", event: &refusedEvent{}, want: []string{"error 503"}},
+		{name: "not in family", event: &testEvent{}, want: []string{"any"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&familyStateMachine{})
+			idle := newTestState("idle")
+			spec.DefineStates(idle).SetInitialState(idle)
+			OnAnyEvent(spec, idle, func(_ context.Context, _ AbstractEvent, sm *familyStateMachine) {
+				sm.HandledBy = append(sm.HandledBy, "any")
+			})
+			OnEvent(spec, idle, func(_ context.Context, e testErrorEvent, sm *familyStateMachine) {
+				sm.HandledBy = append(sm.HandledBy, "error "+strconv.Itoa(e.Code()))
+			})
+			OnEvent(spec, idle, func(_ context.Context, _ *timeoutEvent, sm *familyStateMachine) {
+				sm.HandledBy = append(sm.HandledBy, "timeout")
+			})
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			env := initialWorld(sm).env
+			env.queue[sm.id()] = []AbstractEvent{tt.event}
+			lss, err := stepLocal(env, sm.id())
+			if err != nil {
+				t.Fatalf("stepLocal error: %v", err)
+			}
+			if len(lss) != 1 {
+				t.Fatalf("got %d local states, want 1", len(lss))
+			}
+			got := lss[0].env.machines[sm.id()].(*familyStateMachine).HandledBy
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("handlers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOnEventMatching(t *testing.T) {
+	type roomStateMachine struct {
+		StateMachine
+		Rooms []int
+	}
+
+	tests := []struct {
+		name  string
+		value int
+		want  []int
+	}{
+		{name: "first pattern", value: 1, want: []int{1}},
+		{name: "second pattern", value: 2, want: []int{2}},
+		{name: "no pattern", value: 3, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&roomStateMachine{})
+			idle := newTestState("idle")
+			spec.DefineStates(idle).SetInitialState(idle)
+			for _, room := range []int{1, 2} {
+				OnEventMatching(spec, idle, &testEvent{Value: room}, func(_ context.Context, e *testEvent, sm *roomStateMachine) {
+					sm.Rooms = append(sm.Rooms, e.Value)
+				})
+			}
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			env := initialWorld(sm).env
+			env.queue[sm.id()] = []AbstractEvent{&testEvent{Value: tt.value}}
+			lss, err := stepLocal(env, sm.id())
+			if err != nil {
+				t.Fatalf("stepLocal error: %v", err)
+			}
+			if len(lss) != 1 {
+				t.Fatalf("got %d local states, want 1", len(lss))
+			}
+			got := lss[0].env.machines[sm.id()].(*roomStateMachine).Rooms
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("rooms mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchesPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern AbstractEvent
+		event   AbstractEvent
+		want    bool
+	}{
+		{name: "zero pattern matches any value", pattern: &testEvent{}, event: &testEvent{Value: 5}, want: true},
+		{name: "equal field", pattern: &testEvent{Value: 5}, event: &testEvent{Value: 5}, want: true},
+		{name: "different field", pattern: &testEvent{Value: 5}, event: &testEvent{Value: 6}, want: false},
+		{name: "different type", pattern: &testEvent{}, event: &timeoutEvent{}, want: false},
+		{name: "generic payload", pattern: &genericTestEvent[string]{Payload: "a"}, event: &genericTestEvent[string]{Payload: "a"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesPattern(tt.pattern, tt.event); got != tt.want {
+				t.Errorf("matchesPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package goat
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AbstractGhost is implemented by Ghost variables of any value type so they
+// can be registered together with WithGhosts.
+type AbstractGhost interface {
+	ghostName() string
+	initialValue() any
+	update(s Step, v any) any
+	hidden() bool
+}
+
+// Ghost is a verification-only variable attached to the model rather than to
+// a state machine. It starts with an initial value and is updated after every
+// step, which lets properties refer to the history of a run ("a room was
+// never reserved twice") without adding fields to production-shaped machines.
+//
+// Ghost values are shown in traces and Debug output. By default they are part
+// of world identity; see ExcludeFromIdentity.
+type Ghost[V any] struct {
+	name    string
+	init    V
+	fn      func(Step, V) V
+	exclude bool
+}
+
+// NewGhost creates a ghost variable.
+//
+// The update function receives each step taken by the system and the current
+// value, and returns the next value. It must not modify the current value in
+// place: return a copy when V is a map, slice or pointer.
+//
+// Parameters:
+//   - name: Name shown in traces
+//   - init: Value in the initial world
+//   - update: Function computing the value after a step
+//
+// Returns the ghost variable, to be registered with WithGhosts.
+//
+// Example:
+//
+//	reservations := goat.NewGhost("reservations", 0, func(s goat.Step, n int) int {
+//	    if _, ok := s.Event.(*ReservationResultEvent); ok {
+//	        return n + 1
+//	    }
+//	    return n
+//	})
+func NewGhost[V any](name string, init V, update func(Step, V) V) *Ghost[V] {
+	return &Ghost[V]{name: name, init: init, fn: update}
+}
+
+// ExcludeFromIdentity keeps the ghost out of world identity: two worlds that
+// differ only in this ghost are merged, keeping the value of the first one
+// explored. This shrinks the state space but is only sound when no property
+// distinguishes the merged values, for example a ghost used purely for
+// reporting.
+//
+// Returns the ghost for method chaining.
+func (g *Ghost[V]) ExcludeFromIdentity() *Ghost[V] {
+	g.exclude = true
+	return g
+}
+
+func (g *Ghost[V]) ghostName() string { return g.name }
+func (g *Ghost[V]) initialValue() any { return g.init }
+func (g *Ghost[V]) hidden() bool      { return g.exclude }
+
+func (g *Ghost[V]) update(s Step, v any) any {
+	return g.fn(s, v.(V))
+}
+
+// WithGhosts registers ghost variables with the model.
+//
+// Parameters:
+//   - gs: Ghost variables created with NewGhost
+//
+// Returns an Option that can be passed to Test() or Debug().
+//
+// Example:
+//
+//	err := goat.Test(
+//	    goat.WithStateMachines(server, client),
+//	    goat.WithGhosts(reservations),
+//	    goat.WithRules(goat.Always(goat.NewGhostCondition("at most one reservation", reservations,
+//	        func(n int) bool { return n <= 1 }))),
+//	)
+func WithGhosts(gs ...AbstractGhost) Option {
+	return optionFunc(func(o *options) {
+		o.ghosts = append(o.ghosts, gs...)
+	})
+}
+
+type ghostValue struct {
+	value  any
+	hidden bool
+}
+
+// GetGhost returns the value of g in the world being evaluated.
+//
+// Parameters:
+//   - m: Machines accessor provided to the check function
+//   - g: The ghost variable to read
+//
+// Returns the value and true, or the zero value and false when g is not
+// registered with the model.
+//
+// Example:
+//
+//	cond := goat.NewMultiCondition("no double booking", func(ms goat.Machines) bool {
+//	    n, _ := goat.GetGhost(ms, reservations)
+//	    return n <= 1
+//	})
+func GetGhost[V any](m Machines, g *Ghost[V]) (V, bool) {
+	var zero V
+	impl, ok := m.(*machinesImpl)
+	if !ok {
+		return zero, false
+	}
+	gv, ok := impl.world.env.ghosts[g.name]
+	if !ok {
+		return zero, false
+	}
+	v, ok := gv.value.(V)
+	return v, ok
+}
+
+// NewGhostCondition creates a condition over the value of a ghost variable.
+// It does not hold when g is not registered with the model.
+//
+// Parameters:
+//   - name: The condition name
+//   - g: The ghost variable to read
+//   - check: Predicate over the ghost value
+//
+// Returns a Condition that can be used with Test() (for example via WithRules(Always(...))).
+func NewGhostCondition[V any](name string, g *Ghost[V], check func(V) bool) Condition {
+	return NewMultiCondition(name, func(ms Machines) bool {
+		v, ok := GetGhost(ms, g)
+		if !ok {
+			return false
+		}
+		return check(v)
+	})
+}
+
+// initGhosts sets the initial ghost values in env.
+func initGhosts(env *environment, gs []AbstractGhost) {
+	if len(gs) == 0 {
+		return
+	}
+	env.ghosts = make(map[string]ghostValue, len(gs))
+	for _, g := range gs {
+		env.ghosts[g.ghostName()] = ghostValue{value: g.initialValue(), hidden: g.hidden()}
+	}
+}
+
+// applyGhosts updates the ghost values of next, reached from prev by tr, and
+// returns the resulting world.
+func (m *model) applyGhosts(prev, next world, tr *transition) world {
+	if len(m.ghosts) == 0 {
+		return next
+	}
+	s := tr.step(next.env)
+	next.env.ghosts = make(map[string]ghostValue, len(m.ghosts))
+	for _, g := range m.ghosts {
+		gv := prev.env.ghosts[g.ghostName()]
+		gv.value = g.update(s, gv.value)
+		next.env.ghosts[g.ghostName()] = gv
+	}
+	next = newWorld(next.env)
+	tr.to = next.id
+	return next
+}
+
+// ghostLines returns "name = value" for each ghost in env, sorted by name.
+func ghostLines(env environment) []string {
+	if len(env.ghosts) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(env.ghosts))
+	for name := range env.ghosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("%s = %+v", name, env.ghosts[name].value)
+	}
+	return lines
+}
@@ -0,0 +1,99 @@
+package goat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// newMoveCounter returns a ghost counting transitions, saturating at 2 so
+// that the state space stays finite.
+func newMoveCounter() *Ghost[int] {
+	return NewGhost("moves", 0, func(s Step, n int) int {
+		if _, ok := s.Event.(*transitionEvent); ok && n < 2 {
+			return n + 1
+		}
+		return n
+	})
+}
+
+func TestGhost(t *testing.T) {
+	tests := []struct {
+		name      string
+		loop      bool
+		threshold int
+		want      bool
+	}{
+		{name: "single move stays within bound", loop: false, threshold: 1, want: false},
+		{name: "loop moves twice", loop: true, threshold: 2, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, _, _ := newTwoStateTestMachine(tt.loop)
+			moves := newMoveCounter()
+			cond := NewGhostCondition("moved enough", moves, func(n int) bool { return n >= 2 })
+			m := newSolvedTestModel(t,
+				WithStateMachines(sm),
+				WithGhosts(moves),
+				WithRules(Reachable(cond)),
+			)
+			if got := m.checkReachability()[0].Satisfied; got != tt.want {
+				t.Errorf("Satisfied = %v, want %v", got, tt.want)
+			}
+			if v := m.initial.env.ghosts["moves"].value; v != 0 {
+				t.Errorf("initial ghost value = %v, want 0", v)
+			}
+		})
+	}
+}
+
+func TestGhost_violationOutput(t *testing.T) {
+	sm, _, _ := newTwoStateTestMachine(true)
+	moves := newMoveCounter()
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithGhosts(moves),
+		WithRules(Always(NewGhostCondition("moved at most once", moves, func(n int) bool { return n <= 1 }))),
+	)
+
+	var buf bytes.Buffer
+	m.writeInvariantViolations(&buf)
+	got := buf.String()
+	if !strings.Contains(got, "  Ghosts:\n    moves = 0\n") || !strings.HasSuffix(got, "  Ghosts:\n    moves = 2\n") {
+		t.Errorf("expected ghost values in trace:\n%s", got)
+	}
+}
+
+func TestGhost_ExcludeFromIdentity(t *testing.T) {
+	count := func(opts ...Option) int {
+		sm, _, _ := newTwoStateTestMachine(true)
+		m := newSolvedTestModel(t, append([]Option{WithStateMachines(sm)}, opts...)...)
+		return len(m.worlds)
+	}
+
+	plain := count()
+	visible := count(WithGhosts(newMoveCounter()))
+	hidden := count(WithGhosts(newMoveCounter().ExcludeFromIdentity()))
+	if visible <= plain {
+		t.Errorf("visible ghost should split worlds: %d worlds, want more than %d", visible, plain)
+	}
+	if hidden != plain {
+		t.Errorf("hidden ghost should not split worlds: %d worlds, want %d", hidden, plain)
+	}
+}
+
+func TestGetGhost(t *testing.T) {
+	moves := newMoveCounter()
+	other := NewGhost("other", "x", func(_ Step, v string) string { return v })
+	w := initialWorld(newTestStateMachine(newTestState("s")))
+	initGhosts(&w.env, []AbstractGhost{moves})
+	ms := &machinesImpl{world: w}
+
+	if v, ok := GetGhost(ms, moves); !ok || v != 0 {
+		t.Errorf("GetGhost(moves) = %v, %v; want 0, true", v, ok)
+	}
+	if _, ok := GetGhost(ms, other); ok {
+		t.Errorf("GetGhost(other) should fail for unregistered ghost")
+	}
+}
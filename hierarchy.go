@@ -0,0 +1,254 @@
+package goat
+
+import (
+	"fmt"
+	"strings"
+)
+
+type substate struct {
+	parent AbstractState
+	child  AbstractState
+}
+
+// enterStateEvent and exitStateEvent enter and exit a single state of a
+// hierarchical machine. They run the entry and exit handlers registered for
+// that state, whichever substate the machine is in.
+type enterStateEvent struct {
+	UnTypedEvent
+	State AbstractState
+}
+
+type exitStateEvent struct {
+	UnTypedEvent
+	State AbstractState
+}
+
+// DefineSubstates nests children inside parent. Children that are not
+// defined yet are added to the spec's states, so DefineSubstates must be
+// called after DefineStates. The first child is the initial substate:
+// entering parent, including with Goto or SetInitialState, enters it too.
+//
+// A machine in a substate is also in all of its ancestors. Events without a
+// handler in the current state are handled by the nearest ancestor that has
+// one, and Goto exits and enters every state between the source and the
+// target, innermost first on exit and outermost first on entry.
+//
+// Parameters:
+//   - parent: The enclosing state
+//   - children: The substates, the first one being the initial substate
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	spec.DefineStates(disconnected, connected).
+//	    DefineSubstates(connected, idle, busy).
+//	    SetInitialState(disconnected)
+//	// Handles Disconnect in both idle and busy.
+//	goat.OnEvent(spec, connected, func(ctx context.Context, e *DisconnectEvent, sm *Client) {
+//	    goat.Goto(ctx, disconnected)
+//	})
+func (spec *StateMachineSpec[T]) DefineSubstates(parent AbstractState, children ...AbstractState) *StateMachineSpec[T] {
+	for _, child := range children {
+		defined := false
+		for _, s := range spec.states {
+			if sameState(s, child) {
+				defined = true
+				break
+			}
+		}
+		if !defined {
+			spec.states = append(spec.states, child)
+			spec.setDefaultHandlerBuilders(child)
+		}
+		spec.substates = append(spec.substates, substate{parent: parent, child: child})
+	}
+	return spec
+}
+
+func (spec *StateMachineSpec[T]) validateSubstates() error {
+	for i, s := range spec.substates {
+		for _, other := range spec.substates[:i] {
+			if sameState(s.child, other.child) && !sameState(s.parent, other.parent) {
+				return fmt.Errorf("state %s has more than one parent", getStateName(s.child))
+			}
+		}
+	}
+	sm := &StateMachine{substates: spec.substates}
+	for _, s := range spec.substates {
+		seen := 0
+		for state, ok := s.child, true; ok; state, ok = sm.parentOf(state) {
+			if seen++; seen > len(spec.substates)+1 {
+				return fmt.Errorf("state %s is its own ancestor", getStateName(s.child))
+			}
+		}
+	}
+	return nil
+}
+
+func (sm *StateMachine) isHierarchical() bool {
+	return len(sm.substates) > 0
+}
+
+func (sm *StateMachine) parentOf(state AbstractState) (AbstractState, bool) {
+	for _, s := range sm.substates {
+		if sameState(s.child, state) {
+			return s.parent, true
+		}
+	}
+	return nil, false
+}
+
+func (sm *StateMachine) initialSubstate(state AbstractState) (AbstractState, bool) {
+	for _, s := range sm.substates {
+		if sameState(s.parent, state) {
+			return s.child, true
+		}
+	}
+	return nil, false
+}
+
+// stateChain returns state followed by its ancestors, innermost first.
+func (sm *StateMachine) stateChain(state AbstractState) []AbstractState {
+	chain := []AbstractState{state}
+	for parent, ok := sm.parentOf(state); ok; parent, ok = sm.parentOf(parent) {
+		chain = append(chain, parent)
+	}
+	return chain
+}
+
+// leafOf returns the innermost state entered when entering state.
+func (sm *StateMachine) leafOf(state AbstractState) AbstractState {
+	for child, ok := sm.initialSubstate(state); ok; child, ok = sm.initialSubstate(child) {
+		state = child
+	}
+	return state
+}
+
+// transitionPath returns the states exited, innermost first, and entered,
+// outermost first, when going from the leaf state from to target. Like a
+// flat Goto, going to the current state or one of its ancestors exits and
+// re-enters it.
+func (sm *StateMachine) transitionPath(from, target AbstractState) (exits, entries []AbstractState) {
+	ancestors := sm.stateChain(target)[1:]
+	isAncestor := func(s AbstractState) bool {
+		for _, a := range ancestors {
+			if sameState(a, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var common AbstractState
+	for _, s := range sm.stateChain(from) {
+		if isAncestor(s) {
+			common = s
+			break
+		}
+		exits = append(exits, s)
+	}
+
+	down := sm.stateChain(sm.leafOf(target))
+	for i := len(down) - 1; i >= 0; i-- {
+		if common != nil && !isBelow(sm, down[i], common) {
+			continue
+		}
+		entries = append(entries, down[i])
+	}
+	return exits, entries
+}
+
+// isBelow reports whether state is a strict descendant of ancestor.
+func isBelow(sm *StateMachine, state, ancestor AbstractState) bool {
+	for _, s := range sm.stateChain(state)[1:] {
+		if sameState(s, ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// initialEvents returns the events queued for sm before its first step:
+// entering its initial state, with its ancestors in hierarchical machines,
+// then the initial state of each region.
+func initialEvents(sm AbstractStateMachine) []AbstractEvent {
+	innerSM := getInnerStateMachine(sm)
+	var events []AbstractEvent
+	if innerSM.isHierarchical() {
+		chain := innerSM.stateChain(sm.currentState())
+		for i := len(chain) - 1; i >= 0; i-- {
+			events = append(events, &enterStateEvent{State: chain[i]})
+		}
+	} else {
+		events = append(events, &entryEvent{})
+	}
+	for _, r := range innerSM.regions {
+		events = append(events, &enterStateEvent{State: r.current})
+	}
+	return events
+}
+
+// inState reports whether sm is in state or one of its substates.
+func inState(sm AbstractStateMachine, state AbstractState) bool {
+	innerSM := getInnerStateMachine(sm)
+	if !innerSM.isHierarchical() {
+		return sameState(sm.currentState(), state)
+	}
+	for _, s := range innerSM.stateChain(sm.currentState()) {
+		if sameState(s, state) {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerScopes returns the states whose handlers may handle event,
+// innermost first, and the event their handlers are registered for.
+func handlerScopes(sm AbstractStateMachine, event AbstractEvent) ([]AbstractState, AbstractEvent) {
+	switch e := event.(type) {
+	case *enterStateEvent:
+		return []AbstractState{e.State}, &entryEvent{}
+	case *exitStateEvent:
+		return []AbstractState{e.State}, &exitEvent{}
+	}
+	return getInnerStateMachine(sm).stateChain(sm.currentState()), event
+}
+
+func handlersFor(sm *StateMachine, state AbstractState) []handlerInfo {
+	for s, his := range sm.EventHandlers {
+		if sameState(s, state) {
+			return his
+		}
+	}
+	return nil
+}
+
+func isDefaultHandler(h handler) bool {
+	switch h.(type) {
+	case *defaultOnTransitionHandler, *defaultOnHaltHandler:
+		return true
+	default:
+		return false
+	}
+}
+
+// describeState returns the current state of sm for traces and world
+// identity: the state details, preceded by those of its ancestors in
+// hierarchical machines and followed by the state of each region.
+func describeState(sm AbstractStateMachine) string {
+	innerSM := getInnerStateMachine(sm)
+	desc := getStateDetails(sm.currentState())
+	if innerSM.isHierarchical() {
+		chain := innerSM.stateChain(sm.currentState())
+		parts := make([]string, len(chain))
+		for i, s := range chain {
+			parts[len(chain)-1-i] = getStateDetails(s)
+		}
+		desc = strings.Join(parts, " > ")
+	}
+	if len(innerSM.regions) > 0 {
+		desc += " | " + describeRegions(innerSM)
+	}
+	return desc
+}
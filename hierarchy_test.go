@@ -0,0 +1,151 @@
+package goat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStateMachine_transitionPath(t *testing.T) {
+	a, a1, a2 := newTestState("a"), newTestState("a1"), newTestState("a2")
+	a1x, a1y := newTestState("a1x"), newTestState("a1y")
+	b := newTestState("b")
+	sm := &StateMachine{substates: []substate{
+		{parent: a, child: a1}, {parent: a, child: a2},
+		{parent: a1, child: a1x}, {parent: a1, child: a1y},
+	}}
+	names := func(states []AbstractState) []string {
+		var ns []string
+		for _, s := range states {
+			ns = append(ns, s.(*testState).Name)
+		}
+		return ns
+	}
+
+	tests := []struct {
+		name        string
+		from, to    AbstractState
+		wantExits   []string
+		wantEntries []string
+	}{
+		{name: "sibling", from: a1x, to: a1y, wantExits: []string{"a1x"}, wantEntries: []string{"a1y"}},
+		{name: "out of hierarchy", from: a1x, to: b, wantExits: []string{"a1x", "a1", "a"}, wantEntries: []string{"b"}},
+		{name: "into hierarchy", from: b, to: a, wantExits: []string{"b"}, wantEntries: []string{"a", "a1", "a1x"}},
+		{name: "self", from: a1x, to: a1x, wantExits: []string{"a1x"}, wantEntries: []string{"a1x"}},
+		{name: "to ancestor", from: a1y, to: a, wantExits: []string{"a1y", "a1", "a"}, wantEntries: []string{"a", "a1", "a1x"}},
+		{name: "to cousin", from: a1y, to: a2, wantExits: []string{"a1y", "a1"}, wantEntries: []string{"a2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exits, entries := sm.transitionPath(tt.from, tt.to)
+			if diff := cmp.Diff(tt.wantExits, names(exits)); diff != "" {
+				t.Errorf("exits mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEntries, names(entries)); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStateMachineSpec_DefineSubstates(t *testing.T) {
+	type clientStateMachine struct {
+		StateMachine
+		Log string
+	}
+
+	spec := NewStateMachineSpec(&clientStateMachine{})
+	disconnected := newTestState("disconnected")
+	connected := newTestState("connected")
+	idle := newTestState("idle")
+	busy := newTestState("busy")
+	spec.
+		DefineStates(disconnected, connected).
+		DefineSubstates(connected, idle, busy).
+		SetInitialState(connected)
+	for _, s := range []*testState{disconnected, connected, idle, busy} {
+		OnEntry(spec, s, func(ctx context.Context, sm *clientStateMachine) {
+			sm.Log += "+" + s.Name
+			if s == idle {
+				SendTo(ctx, sm, &testEvent{})
+			}
+		})
+		OnExit(spec, s, func(_ context.Context, sm *clientStateMachine) {
+			sm.Log += "-" + s.Name
+		})
+	}
+	OnEvent(spec, connected, func(ctx context.Context, _ *testEvent, _ *clientStateMachine) {
+		Goto(ctx, disconnected)
+	})
+
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+	if !sameState(sm.currentState(), idle) {
+		t.Fatalf("initial state = %v, want the initial substate idle", sm.currentState())
+	}
+	if got, want := describeState(sm), "{Name:Name,Type:string,Value:connected} > {Name:Name,Type:string,Value:idle}"; got != want {
+		t.Errorf("describeState() = %q, want %q", got, want)
+	}
+
+	m := newSolvedTestModel(t, WithStateMachines(sm))
+
+	var logs []string
+	for id, w := range m.worlds {
+		if len(m.accessible[id]) > 0 {
+			continue
+		}
+		final := w.env.machines[sm.id()].(*clientStateMachine)
+		if !sameState(final.currentState(), disconnected) {
+			t.Errorf("final state = %v, want disconnected", final.currentState())
+		}
+		logs = append(logs, final.Log)
+	}
+	want := []string{"+connected+idle-idle-connected+disconnected"}
+	if diff := cmp.Diff(want, logs); diff != "" {
+		t.Errorf("entry/exit order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStateMachineSpec_validateSubstates(t *testing.T) {
+	a, b, c := newTestState("a"), newTestState("b"), newTestState("c")
+	tests := []struct {
+		name    string
+		define  func(spec *StateMachineSpec[*testStateMachine])
+		wantErr bool
+	}{
+		{
+			name:   "tree",
+			define: func(spec *StateMachineSpec[*testStateMachine]) { spec.DefineSubstates(a, b, c) },
+		},
+		{
+			name: "two parents",
+			define: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineSubstates(a, c).DefineSubstates(b, c)
+			},
+			wantErr: true,
+		},
+		{
+			name: "cycle",
+			define: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineSubstates(a, b).DefineSubstates(b, a)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&testStateMachine{})
+			spec.DefineStates(a).SetInitialState(a)
+			tt.define(spec)
+			_, err := spec.NewInstance()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
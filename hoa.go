@@ -0,0 +1,603 @@
+package goat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RuleFromHOA builds a rule from an automaton in the Hanoi Omega-Automata
+// (HOA v1) format, as produced by tools such as Spot or ltl3ba.
+//
+// The automaton must describe the violating behaviors, i.e. it should be the
+// translation of the negated property (for example the output of
+// `ltl2tgba '!G(p -> F q)'`). Büchi acceptance (`Inf(0)`) is supported with
+// either state-based or transition-based marks, as are the trivial `t` and
+// `f` acceptance conditions. Atomic propositions are resolved by name in aps.
+//
+// Parameters:
+//   - r: Reader providing the HOA document
+//   - aps: Conditions for every atomic proposition declared by the AP header
+//
+// Returns a Rule that can be registered with WithRules, or an error when the
+// document cannot be parsed or uses unsupported features.
+//
+// Example:
+//
+//	f, err := os.Open("response.hoa")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	rule, err := goat.RuleFromHOA(f, map[string]goat.Condition{
+//		"p": requested,
+//		"q": responded,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	err = goat.Test(goat.WithStateMachines(client, server), goat.WithRules(rule))
+func RuleFromHOA(r io.Reader, aps map[string]Condition) (Rule, error) {
+	a, name, err := parseHOA(r, aps)
+	if err != nil {
+		return nil, err
+	}
+	return BuchiRule(name, a), nil
+}
+
+type hoaEdge struct {
+	label *Label
+	to    int
+	acc   bool
+}
+
+type hoaAutomaton struct {
+	name       string
+	starts     []int
+	aps        []Condition
+	aliases    map[string]*Label
+	acceptance string
+	accepting  map[int]bool
+	edges      map[int][]hoaEdge
+	numStates  int
+}
+
+func parseHOA(r io.Reader, aps map[string]Condition) (*BuchiAutomaton, string, error) {
+	tokens, err := tokenizeHOA(r)
+	if err != nil {
+		return nil, "", err
+	}
+	p := &hoaParser{tokens: tokens, conds: aps}
+	h, err := p.parse()
+	if err != nil {
+		return nil, "", fmt.Errorf("hoa: %w", err)
+	}
+	a, err := h.toBuchi()
+	if err != nil {
+		return nil, "", fmt.Errorf("hoa: %w", err)
+	}
+	return a, h.name, nil
+}
+
+// toBuchi converts the parsed automaton into a state-based Büchi automaton.
+// Every HOA state q becomes two states: 2q when entered through an ordinary
+// edge and 2q+1 when entered through an edge carrying the acceptance mark.
+// Multiple start states are merged into a fresh initial state.
+func (h *hoaAutomaton) toBuchi() (*BuchiAutomaton, error) {
+	var all bool
+	switch h.acceptance {
+	case "Inf(0)":
+	case "t":
+		all = true
+	case "f":
+	default:
+		return nil, fmt.Errorf("unsupported acceptance condition %q; only Büchi acceptance Inf(0) is supported", h.acceptance)
+	}
+	if len(h.starts) == 0 {
+		return nil, fmt.Errorf("no start state")
+	}
+
+	initial := 2 * h.numStates
+	a := NewBuchiAutomaton(initial)
+	for q := 0; q < h.numStates; q++ {
+		for _, e := range h.edges[q] {
+			to := 2 * e.to
+			if e.acc || h.accepting[e.to] {
+				to++
+			}
+			a.Transition(2*q, to, e.label)
+			a.Transition(2*q+1, to, e.label)
+		}
+		if h.acceptance == "Inf(0)" || all {
+			a.Accepting(2*q + 1)
+		}
+		if all {
+			a.Accepting(2 * q)
+		}
+	}
+	for _, s := range h.starts {
+		for _, e := range h.edges[s] {
+			to := 2 * e.to
+			if e.acc || h.accepting[e.to] {
+				to++
+			}
+			a.Transition(initial, to, e.label)
+		}
+	}
+	return a, nil
+}
+
+type hoaTokenKind int
+
+const (
+	hoaHeader hoaTokenKind = iota
+	hoaString
+	hoaInt
+	hoaIdent
+	hoaAlias
+	hoaPunct
+	hoaSeparator
+)
+
+type hoaToken struct {
+	kind hoaTokenKind
+	text string
+	line int
+}
+
+func tokenizeHOA(r io.Reader) ([]hoaToken, error) {
+	var tokens []hoaToken
+	scanner := bufio.NewScanner(r)
+	line := 0
+	inComment := false
+	for scanner.Scan() {
+		line++
+		src := []rune(scanner.Text())
+		for i := 0; i < len(src); {
+			c := src[i]
+			switch {
+			case inComment:
+				if c == '*' && i+1 < len(src) && src[i+1] == '/' {
+					inComment = false
+					i += 2
+				} else {
+					i++
+				}
+			case unicode.IsSpace(c):
+				i++
+			case c == '/' && i+1 < len(src) && src[i+1] == '*':
+				inComment = true
+				i += 2
+			case c == '"':
+				text, j, ok := scanHOAString(src, i)
+				if !ok {
+					return nil, fmt.Errorf("hoa: line %d: unterminated string", line)
+				}
+				tokens = append(tokens, hoaToken{kind: hoaString, text: text, line: line})
+				i = j
+			case c == '-' && strings.HasPrefix(string(src[i:]), "--"):
+				j := i + 2
+				for j < len(src) && !unicode.IsSpace(src[j]) {
+					j++
+				}
+				tokens = append(tokens, hoaToken{kind: hoaSeparator, text: string(src[i:j]), line: line})
+				i = j
+			case unicode.IsDigit(c):
+				j := i
+				for j < len(src) && unicode.IsDigit(src[j]) {
+					j++
+				}
+				tokens = append(tokens, hoaToken{kind: hoaInt, text: string(src[i:j]), line: line})
+				i = j
+			case c == '@' || c == '_' || unicode.IsLetter(c):
+				kind, j := scanHOAWord(src, i)
+				tokens = append(tokens, hoaToken{kind: kind, text: string(src[i:j]), line: line})
+				i = j
+			case strings.ContainsRune("[]{}()!&|", c):
+				tokens = append(tokens, hoaToken{kind: hoaPunct, text: string(c), line: line})
+				i++
+			default:
+				return nil, fmt.Errorf("hoa: line %d: unexpected character %q", line, c)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// scanHOAString reads the double-quoted string starting at src[i] and returns
+// its unescaped contents and the index just past the closing quote.
+func scanHOAString(src []rune, i int) (string, int, bool) {
+	var sb strings.Builder
+	j := i + 1
+	for ; j < len(src) && src[j] != '"'; j++ {
+		if src[j] == '\\' && j+1 < len(src) {
+			j++
+		}
+		sb.WriteRune(src[j])
+	}
+	if j >= len(src) {
+		return "", j, false
+	}
+	return sb.String(), j + 1, true
+}
+
+// scanHOAWord reads an identifier, alias (@name) or header name (name:)
+// starting at src[i] and returns its kind and the index just past it.
+func scanHOAWord(src []rune, i int) (hoaTokenKind, int) {
+	j := i + 1
+	for j < len(src) && (src[j] == '_' || src[j] == '-' || unicode.IsLetter(src[j]) || unicode.IsDigit(src[j])) {
+		j++
+	}
+	if src[i] == '@' {
+		return hoaAlias, j
+	}
+	if j < len(src) && src[j] == ':' {
+		return hoaHeader, j + 1
+	}
+	return hoaIdent, j
+}
+
+type hoaParser struct {
+	tokens []hoaToken
+	pos    int
+	conds  map[string]Condition
+}
+
+func (p *hoaParser) peek() (hoaToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return hoaToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *hoaParser) next() (hoaToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return hoaToken{}, fmt.Errorf("unexpected end of input")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *hoaParser) peekIs(kind hoaTokenKind, text string) bool {
+	t, ok := p.peek()
+	return ok && t.kind == kind && t.text == text
+}
+
+func (p *hoaParser) expect(kind hoaTokenKind, text string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.kind != kind || t.text != text {
+		return fmt.Errorf("line %d: expected %q, got %q", t.line, text, t.text)
+	}
+	return nil
+}
+
+func (p *hoaParser) int() (int, error) {
+	t, err := p.next()
+	if err != nil {
+		return 0, err
+	}
+	if t.kind != hoaInt {
+		return 0, fmt.Errorf("line %d: expected integer, got %q", t.line, t.text)
+	}
+	return strconv.Atoi(t.text)
+}
+
+func (p *hoaParser) parse() (*hoaAutomaton, error) {
+	h := &hoaAutomaton{
+		name:      "HOA automaton",
+		aliases:   make(map[string]*Label),
+		accepting: make(map[int]bool),
+		edges:     make(map[int][]hoaEdge),
+	}
+	if err := p.expect(hoaHeader, "HOA:"); err != nil {
+		return nil, err
+	}
+	if err := p.expect(hoaIdent, "v1"); err != nil {
+		return nil, err
+	}
+	if err := p.parseHeader(h); err != nil {
+		return nil, err
+	}
+	if err := p.expect(hoaSeparator, "--BODY--"); err != nil {
+		return nil, err
+	}
+	if err := p.parseBody(h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (p *hoaParser) parseHeader(h *hoaAutomaton) error {
+	for {
+		t, ok := p.peek()
+		if !ok {
+			return fmt.Errorf("missing --BODY--")
+		}
+		if t.kind == hoaSeparator {
+			return nil
+		}
+		p.pos++
+		if t.kind != hoaHeader {
+			return fmt.Errorf("line %d: expected header item, got %q", t.line, t.text)
+		}
+		var err error
+		switch t.text {
+		case "name:":
+			var s hoaToken
+			if s, err = p.next(); err == nil {
+				h.name = s.text
+			}
+		case "States:":
+			h.numStates, err = p.int()
+		case "Start:":
+			var s int
+			if s, err = p.int(); err == nil {
+				if p.peekIs(hoaPunct, "&") {
+					return fmt.Errorf("line %d: alternating start states are not supported", t.line)
+				}
+				h.starts = append(h.starts, s)
+			}
+		case "AP:":
+			err = p.parseAPs(h)
+		case "Alias:":
+			var alias hoaToken
+			if alias, err = p.next(); err == nil {
+				if alias.kind != hoaAlias {
+					return fmt.Errorf("line %d: expected alias name, got %q", alias.line, alias.text)
+				}
+				h.aliases[alias.text], err = p.parseLabelExpr(h)
+			}
+		case "Acceptance:":
+			err = p.parseAcceptance(h)
+		default:
+			p.skipHeaderValues()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *hoaParser) parseAPs(h *hoaAutomaton) error {
+	n, err := p.int()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		t, err := p.next()
+		if err != nil {
+			return err
+		}
+		if t.kind != hoaString {
+			return fmt.Errorf("line %d: expected proposition name, got %q", t.line, t.text)
+		}
+		cond, ok := p.conds[t.text]
+		if !ok || cond == nil {
+			return fmt.Errorf("line %d: no condition provided for atomic proposition %q", t.line, t.text)
+		}
+		h.aps = append(h.aps, cond)
+	}
+	return nil
+}
+
+func (p *hoaParser) parseAcceptance(h *hoaAutomaton) error {
+	if _, err := p.int(); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == hoaHeader || t.kind == hoaSeparator {
+			break
+		}
+		p.pos++
+		sb.WriteString(t.text)
+	}
+	h.acceptance = sb.String()
+	return nil
+}
+
+func (p *hoaParser) skipHeaderValues() {
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == hoaHeader || t.kind == hoaSeparator {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *hoaParser) parseBody(h *hoaAutomaton) error {
+	state := -1
+	var stateLabel *Label
+	for {
+		t, err := p.next()
+		if err != nil {
+			return fmt.Errorf("missing --END--")
+		}
+		switch {
+		case t.kind == hoaSeparator && t.text == "--END--":
+			return nil
+		case t.kind == hoaSeparator:
+			return fmt.Errorf("line %d: unexpected %s", t.line, t.text)
+		case t.kind == hoaHeader && t.text == "State:":
+			stateLabel = nil
+			if p.peekIs(hoaPunct, "[") {
+				if stateLabel, err = p.parseBracketLabel(h); err != nil {
+					return err
+				}
+			}
+			if state, err = p.int(); err != nil {
+				return err
+			}
+			if state >= h.numStates {
+				h.numStates = state + 1
+			}
+			if next, ok := p.peek(); ok && next.kind == hoaString {
+				p.pos++
+			}
+			acc, err := p.parseAccSets()
+			if err != nil {
+				return err
+			}
+			h.accepting[state] = acc
+		case state < 0:
+			return fmt.Errorf("line %d: edge outside of a state", t.line)
+		default:
+			p.pos--
+			e, err := p.parseEdge(h, stateLabel)
+			if err != nil {
+				return err
+			}
+			if e.to >= h.numStates {
+				h.numStates = e.to + 1
+			}
+			h.edges[state] = append(h.edges[state], e)
+		}
+	}
+}
+
+func (p *hoaParser) parseEdge(h *hoaAutomaton, stateLabel *Label) (hoaEdge, error) {
+	var e hoaEdge
+	var err error
+	if p.peekIs(hoaPunct, "[") {
+		if e.label, err = p.parseBracketLabel(h); err != nil {
+			return e, err
+		}
+	} else {
+		if stateLabel == nil {
+			t, _ := p.peek()
+			return e, fmt.Errorf("line %d: implicit edge labels are not supported", t.line)
+		}
+		e.label = stateLabel
+	}
+	if e.to, err = p.int(); err != nil {
+		return e, err
+	}
+	if p.peekIs(hoaPunct, "&") {
+		t, _ := p.peek()
+		return e, fmt.Errorf("line %d: alternating automata are not supported", t.line)
+	}
+	e.acc, err = p.parseAccSets()
+	return e, err
+}
+
+// parseAccSets parses an optional acceptance set list and reports whether it
+// contains set 0.
+func (p *hoaParser) parseAccSets() (bool, error) {
+	if !p.peekIs(hoaPunct, "{") {
+		return false, nil
+	}
+	p.pos++
+	acc := false
+	for !p.peekIs(hoaPunct, "}") {
+		n, err := p.int()
+		if err != nil {
+			return false, err
+		}
+		if n == 0 {
+			acc = true
+		}
+	}
+	p.pos++
+	return acc, nil
+}
+
+func (p *hoaParser) parseBracketLabel(h *hoaAutomaton) (*Label, error) {
+	if err := p.expect(hoaPunct, "["); err != nil {
+		return nil, err
+	}
+	l, err := p.parseLabelExpr(h)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(hoaPunct, "]"); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// parseLabelExpr parses a disjunction of conjunctions, following the HOA
+// precedence of ! over & over |.
+func (p *hoaParser) parseLabelExpr(h *hoaAutomaton) (*Label, error) {
+	var ors []*Label
+	for {
+		var ands []*Label
+		for {
+			l, err := p.parseLabelAtom(h)
+			if err != nil {
+				return nil, err
+			}
+			ands = append(ands, l)
+			if !p.peekIs(hoaPunct, "&") {
+				break
+			}
+			p.pos++
+		}
+		if len(ands) == 1 {
+			ors = append(ors, ands[0])
+		} else {
+			ors = append(ors, LabelAnd(ands...))
+		}
+		if !p.peekIs(hoaPunct, "|") {
+			break
+		}
+		p.pos++
+	}
+	if len(ors) == 1 {
+		return ors[0], nil
+	}
+	return LabelOr(ors...), nil
+}
+
+func (p *hoaParser) parseLabelAtom(h *hoaAutomaton) (*Label, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case t.kind == hoaPunct && t.text == "!":
+		l, err := p.parseLabelAtom(h)
+		if err != nil {
+			return nil, err
+		}
+		return LabelNot(l), nil
+	case t.kind == hoaPunct && t.text == "(":
+		l, err := p.parseLabelExpr(h)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(hoaPunct, ")"); err != nil {
+			return nil, err
+		}
+		return l, nil
+	case t.kind == hoaIdent && t.text == "t":
+		return LabelTrue(), nil
+	case t.kind == hoaIdent && t.text == "f":
+		return LabelNot(LabelTrue()), nil
+	case t.kind == hoaInt:
+		n, _ := strconv.Atoi(t.text)
+		if n >= len(h.aps) {
+			return nil, fmt.Errorf("line %d: atomic proposition %d is not declared", t.line, n)
+		}
+		return LabelOf(h.aps[n]), nil
+	case t.kind == hoaAlias:
+		l, ok := h.aliases[t.text]
+		if !ok {
+			return nil, fmt.Errorf("line %d: undefined alias %s", t.line, t.text)
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("line %d: unexpected %q in label", t.line, t.text)
+	}
+}
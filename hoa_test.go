@@ -0,0 +1,157 @@
+package goat
+
+import (
+	"strings"
+	"testing"
+)
+
+// F(p & G !q), the negation of G(p -> F q), with state-based acceptance.
+const hoaResponseStateAcc = `HOA: v1
+name: "F(p & G!q)"
+States: 2
+Start: 0
+AP: 2 "p" "q"
+acc-name: Buchi
+Acceptance: 1 Inf(0)
+properties: trans-labels explicit-labels state-acc
+--BODY--
+State: 0
+[t] 0
+[0&!1] 1
+State: 1 {0}
+[!1] 1
+--END--
+`
+
+// The same automaton with transition-based acceptance and an alias.
+const hoaResponseTransAcc = `HOA: v1
+States: 2
+Start: 0
+AP: 2 "p" "q"
+Alias: @notq !1
+acc-name: Buchi
+Acceptance: 1 Inf(0)
+--BODY--
+State: 0 "waiting"
+[t] 0
+[0 & @notq] 1
+State: 1 /* pending */
+[@notq] 1 {0}
+--END--
+`
+
+func TestRuleFromHOA(t *testing.T) {
+	tests := []struct {
+		name     string
+		hoa      string
+		p, q     bool
+		wantName string
+		want     bool
+	}{
+		{
+			name:     "state-based acceptance holds",
+			hoa:      hoaResponseStateAcc,
+			p:        true,
+			q:        true,
+			wantName: "F(p & G!q)",
+			want:     true,
+		},
+		{
+			name:     "state-based acceptance violation",
+			hoa:      hoaResponseStateAcc,
+			p:        true,
+			q:        false,
+			wantName: "F(p & G!q)",
+			want:     false,
+		},
+		{
+			name:     "transition-based acceptance holds when trigger never occurs",
+			hoa:      hoaResponseTransAcc,
+			p:        false,
+			q:        false,
+			wantName: "HOA automaton",
+			want:     true,
+		},
+		{
+			name:     "transition-based acceptance violation",
+			hoa:      hoaResponseTransAcc,
+			p:        true,
+			q:        false,
+			wantName: "HOA automaton",
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := RuleFromHOA(strings.NewReader(tt.hoa), map[string]Condition{
+				"p": BoolCondition("p", tt.p),
+				"q": BoolCondition("q", tt.q),
+			})
+			if err != nil {
+				t.Fatalf("RuleFromHOA error: %v", err)
+			}
+			sm := newTestStateMachine(newTestState("s"))
+			m := newSolvedTestModel(t, WithStateMachines(sm), WithRules(rule))
+			res := m.checkLTL()
+			if len(res) != 1 {
+				t.Fatalf("expected one result, got %d", len(res))
+			}
+			if res[0].Rule != tt.wantName {
+				t.Errorf("Rule = %q, want %q", res[0].Rule, tt.wantName)
+			}
+			if res[0].Satisfied != tt.want {
+				t.Errorf("Satisfied = %v, want %v", res[0].Satisfied, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleFromHOA_errors(t *testing.T) {
+	aps := map[string]Condition{"p": BoolCondition("p", true)}
+	tests := []struct {
+		name    string
+		hoa     string
+		wantErr string
+	}{
+		{
+			name:    "missing proposition",
+			hoa:     "HOA: v1\nStart: 0\nAP: 1 \"x\"\nAcceptance: 1 Inf(0)\n--BODY--\n--END--\n",
+			wantErr: `no condition provided for atomic proposition "x"`,
+		},
+		{
+			name:    "generalized acceptance",
+			hoa:     "HOA: v1\nStart: 0\nAP: 1 \"p\"\nAcceptance: 2 Inf(0)&Inf(1)\n--BODY--\nState: 0\n[0] 0 {0 1}\n--END--\n",
+			wantErr: "unsupported acceptance condition",
+		},
+		{
+			name:    "undeclared proposition index",
+			hoa:     "HOA: v1\nStart: 0\nAP: 1 \"p\"\nAcceptance: 1 Inf(0)\n--BODY--\nState: 0\n[1] 0\n--END--\n",
+			wantErr: "atomic proposition 1 is not declared",
+		},
+		{
+			name:    "implicit labels",
+			hoa:     "HOA: v1\nStart: 0\nAP: 1 \"p\"\nAcceptance: 1 Inf(0)\n--BODY--\nState: 0\n0\n1\n--END--\n",
+			wantErr: "implicit edge labels are not supported",
+		},
+		{
+			name:    "missing end",
+			hoa:     "HOA: v1\nStart: 0\nAP: 1 \"p\"\nAcceptance: 1 Inf(0)\n--BODY--\nState: 0\n[0] 0\n",
+			wantErr: "missing --END--",
+		},
+		{
+			name:    "not a HOA document",
+			hoa:     "digraph {}\n",
+			wantErr: `expected "HOA:"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RuleFromHOA(strings.NewReader(tt.hoa), aps)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -25,12 +25,15 @@ func (m *model) checkLTL() []temporalRuleResult {
 	for _, r := range m.ltlRules {
 		holds, lasso := m.checkBA(r.ba())
 		if !holds {
-			m.hasLTLViolation = true
+			m.hasTemporalViolation = true
 		}
 		result := temporalRuleResult{Rule: r.name(), Satisfied: holds}
 		if lasso != nil {
 			result.Evidence = lasso
 		}
+		if holds {
+			result.Warnings = m.vacuityWarnings(r.triggers, r.operands)
+		}
 		results = append(results, result)
 	}
 	return results
@@ -50,15 +53,10 @@ func (m *model) checkBA(b *ba) (bool, *lasso) {
 	for len(queue) > 0 {
 		n := queue[0]
 		queue = queue[1:]
-		labels := m.labels[n.w]
-		succs := m.accessible[n.w]
-		if len(succs) == 0 {
-			succs = []worldID{n.w}
-		}
-		for _, w2 := range succs {
+		for _, e := range m.outgoing(n.w) {
 			for _, tr := range b.trans[n.s] {
-				if tr.cond(labels) {
-					next := prodNode{w: w2, s: tr.to}
+				if tr.cond(e.labels) {
+					next := prodNode{w: e.to, s: tr.to}
 					graph[n] = append(graph[n], next)
 					if _, ok := pre[next]; !ok {
 						pre[next] = n
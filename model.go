@@ -12,12 +12,27 @@ type model struct {
 	worlds                worlds
 	initial               world
 	accessible            map[worldID][]worldID
+	transitions           map[worldID][]transition
 	conds                 map[ConditionName]Condition
+	stepConds             []ConditionName
 	invariants            []ConditionName
+	stepInvariants        []ConditionName
+	stepViolations        []stepViolation
 	hasInvariantViolation bool
 	ltlRules              []ltlRule
-	hasLTLViolation       bool
+	ctlRules              []ctlRule
+	observerRules         []observerRule
+	boundedRules          []boundedRule
+	reachRules            []reachRule
+	ghosts                []AbstractGhost
+	hasTemporalViolation  bool
 	labels                map[worldID]map[ConditionName]bool
+
+	// hasAbortedStep is set when a handler aborted a step. The explored
+	// state space then misses the worlds the step would have led to.
+	hasAbortedStep bool
+	// stateInvariants holds the state invariants met during the search.
+	stateInvariants map[ConditionName]stateInvariantUse
 }
 
 type worldID uint64
@@ -54,7 +69,7 @@ func id(env environment) worldID {
 	sort.Strings(smIDs)
 	for _, smID := range smIDs {
 		sm := env.machines[smID]
-		strs = append(strs, fmt.Sprintf("%s=%s;%s", sm.id(), getStateMachineDetails(sm), getStateDetails(sm.currentState())))
+		strs = append(strs, fmt.Sprintf("%s=%s;%s", sm.id(), getStateMachineDetails(sm), describeState(sm)))
 	}
 
 	qeNames := make([]string, 0)
@@ -65,6 +80,17 @@ func id(env environment) worldID {
 	}
 	sort.Strings(qeNames)
 	strs = append(strs, qeNames...)
+
+	ghostNames := make([]string, 0, len(env.ghosts))
+	for name, gv := range env.ghosts {
+		if !gv.hidden {
+			ghostNames = append(ghostNames, name)
+		}
+	}
+	sort.Strings(ghostNames)
+	for _, name := range ghostNames {
+		strs = append(strs, fmt.Sprintf("ghost:%s=%+v", name, env.ghosts[name].value))
+	}
 	hasher := fnv.New64a()
 	_, _ = hasher.Write([]byte(strings.Join(strs, ",")))
 	return worldID(hasher.Sum64())
@@ -86,23 +112,10 @@ func initialWorld(sms ...AbstractStateMachine) world {
 			finalID = baseName + "_" + strconv.Itoa(count)
 		}
 
-		// Update the state machine's ID
-		innerSM := getInnerStateMachine(sm)
-		innerSM.smID = finalID
-
-		innerSM.EventHandlers = make(map[AbstractState][]handlerInfo)
-		for state, builders := range innerSM.HandlerBuilders {
-			for _, builderInfo := range builders {
-				handler := builderInfo.builder(finalID)
-				innerSM.EventHandlers[state] = append(innerSM.EventHandlers[state], handlerInfo{
-					event:   builderInfo.event,
-					handler: handler,
-				})
-			}
-		}
+		installHandlers(sm, finalID)
 
 		machines[finalID] = sm
-		queue[finalID] = []AbstractEvent{&entryEvent{}}
+		queue[finalID] = initialEvents(sm)
 		nameCounts[baseName]++
 	}
 
@@ -114,6 +127,29 @@ func initialWorld(sms ...AbstractStateMachine) world {
 	return newWorld(env)
 }
 
+// installHandlers sets the ID of sm and builds its handlers for that ID.
+func installHandlers(sm AbstractStateMachine, smID string) {
+	innerSM := getInnerStateMachine(sm)
+	innerSM.smID = smID
+
+	innerSM.EventHandlers = make(map[AbstractState][]handlerInfo)
+	for state, builders := range innerSM.HandlerBuilders {
+		innerSM.EventHandlers[state] = buildHandlers(smID, builders)
+	}
+	innerSM.anyStateHandlers = buildHandlers(smID, innerSM.anyStateHandlerBuilders)
+}
+
+func buildHandlers(smID string, builders []handlerBuilderInfo) []handlerInfo {
+	var his []handlerInfo
+	for _, builderInfo := range builders {
+		his = append(his, handlerInfo{
+			event:   builderInfo.event,
+			handler: builderInfo.builder(smID),
+		})
+	}
+	return his
+}
+
 func stepLocal(env environment, smID string) ([]localState, error) {
 	ec := env.clone()
 	event, ok := ec.dequeueEvent(smID)
@@ -121,37 +157,134 @@ func stepLocal(env environment, smID string) ([]localState, error) {
 		return nil, nil
 	}
 
-	for _, sm := range ec.machines {
-		if sm.id() == smID {
-			innerSm := getInnerStateMachine(sm)
-			if innerSm.halted {
-				return []localState{{env: env.clone()}}, nil
-			}
-			for state, his := range innerSm.EventHandlers {
-				if sameState(state, sm.currentState()) {
-					lss := make([]localState, 0)
-					for _, hi := range his {
-						if sameEvent(hi.event, event) {
-							states, err := hi.handler.handle(ec, smID, event)
-							if err != nil {
-								return nil, err
-							}
-							lss = append(lss, states...)
-						}
-					}
-					if len(lss) > 0 {
-						return lss, nil
-					}
-					return []localState{{env: ec}}, nil
-				}
+	sm, exists := ec.machines[smID]
+	if !exists {
+		return []localState{{env: ec}}, nil
+	}
+	innerSm := getInnerStateMachine(sm)
+	if innerSm.halted {
+		return []localState{{env: env.clone()}}, nil
+	}
+	if e, ok := event.(*regionTransitionEvent); ok {
+		for i := range innerSm.regions {
+			if innerSm.regions[i].name == e.Region {
+				innerSm.regions[i].current = e.To
 			}
 		}
+		return []localState{{env: ec}}, nil
+	}
+
+	lss, err := runStateHandlers(ec, smID, event)
+	if err != nil {
+		return nil, err
+	}
+	handled := len(lss) > 0
+	if !handled {
+		lss = []localState{{env: ec}}
+	}
+	if len(innerSm.regions) > 0 && !isInternalEvent(event) {
+		var regionHandled bool
+		lss, regionHandled, err = dispatchToRegions(lss, smID, event)
+		if err != nil {
+			return nil, err
+		}
+		handled = handled || regionHandled
+	}
+	if !handled {
+		reportUnhandled(&lss[0].env, smID, event)
+	}
+	for i := range lss {
+		lss[i].env.removeTerminated()
+	}
+	return lss, nil
+}
+
+// runStateHandlers runs the handlers of the main state of smID for event.
+// It returns no local state when none of them handles the event.
+func runStateHandlers(ec environment, smID string, event AbstractEvent) ([]localState, error) {
+	sm := ec.machines[smID]
+
+	// Handlers of the current state come first, then those inherited from
+	// its ancestors, then those registered for every state. Within each,
+	// handlers for the event come before those for any event. In nested
+	// states the default transition and halt handlers only apply when no
+	// state in the chain handles the event.
+	innerSM := getInnerStateMachine(sm)
+	states, match := handlerScopes(sm, event)
+	nested := len(states) > 1
+	for _, state := range states {
+		lss, err := runMostSpecific(ec, smID, handlersFor(innerSM, state), event, match, func(h handler) bool {
+			return !nested || !isDefaultHandler(h)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(lss) > 0 {
+			return lss, nil
+		}
+	}
+	if !isInternalEvent(event) {
+		lss, err := runMostSpecific(ec, smID, innerSM.anyStateHandlers, event, match, func(handler) bool { return true })
+		if err != nil {
+			return nil, err
+		}
+		if len(lss) > 0 {
+			return lss, nil
+		}
+	}
+	if nested {
+		lss, err := runHandlers(ec, smID, handlersFor(innerSM, states[0]), event, match, isDefaultHandler)
+		if err != nil {
+			return nil, err
+		}
+		if len(lss) > 0 {
+			return lss, nil
+		}
+	}
+	return nil, nil
+}
+
+// runHandlers runs the handlers in his registered for match, restricted to
+// those include accepts, on event.
+func runHandlers(env environment, smID string, his []handlerInfo, event, match AbstractEvent, include func(handler) bool) ([]localState, error) {
+	lss := make([]localState, 0)
+	for _, hi := range his {
+		if !matchesEvent(hi.event, match) || !include(hi.handler) {
+			continue
+		}
+		states, err := hi.handler.handle(env, smID, event)
+		if err != nil {
+			return nil, err
+		}
+		lss = append(lss, states...)
 	}
-	return []localState{{env: ec}}, nil
+	return lss, nil
 }
 
-func stepGlobal(w world) ([]world, error) {
+// transition records the step that leads from one world to the next: which
+// machine handled which event and what it sent while doing so.
+type transition struct {
+	to    worldID
+	smID  string
+	event AbstractEvent
+	sent  []sentEvent
+	// failures lists the problems the handlers reported during the step,
+	// such as failed assertions.
+	failures []handlerFailure
+	// aborted is set when a handler panicked during the step. Such a
+	// transition has no target world.
+	aborted bool
+	// choices lists the values picked with Choose during the step.
+	choices []any
+	// labels holds the conditions observed while taking the transition: the
+	// labels of the source world merged with the step conditions. It is nil
+	// when no step condition is registered.
+	labels map[ConditionName]bool
+}
+
+func stepGlobal(w world) ([]world, []transition, error) {
 	ws := make([]world, 0)
+	trs := make([]transition, 0)
 
 	env := w.env
 
@@ -164,16 +297,30 @@ func stepGlobal(w world) ([]world, error) {
 	for _, smID := range smIDs {
 		states, err := stepLocal(env, smID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
+		var event AbstractEvent
+		if i, ok := env.nextEvent(smID); ok && !getInnerStateMachine(env.machines[smID]).halted {
+			event = env.queue[smID][i]
+		}
 		for _, state := range states {
+			tr := transition{smID: smID, event: event}
+			if state.env.step != nil {
+				tr.sent = state.env.step.sent
+				tr.failures = state.env.step.failures
+				tr.aborted = state.env.step.aborted
+				tr.choices = chosenValues(state.env.step.choices)
+				state.env.step = nil
+			}
 			w := newWorld(state.env)
+			tr.to = w.id
 			ws = append(ws, w)
+			trs = append(trs, tr)
 		}
 	}
 
-	return ws, nil
+	return ws, trs, nil
 }
 
 func newModel(opts ...Option) (model, error) {
@@ -182,14 +329,35 @@ func newModel(opts ...Option) (model, error) {
 		return model{}, fmt.Errorf("no state machines provided")
 	}
 	initial := initialWorld(os.sms...)
+	initial.env.unhandledPolicy = os.unhandledPolicy
+	for _, sm := range os.sms {
+		resolveUnhandledPolicy(sm, os.unhandledPolicy)
+	}
+	if len(os.ghosts) > 0 {
+		initGhosts(&initial.env, os.ghosts)
+		initial = newWorld(initial.env)
+	}
 	m := model{
-		initial:    initial,
-		worlds:     make(worlds),
-		accessible: make(map[worldID][]worldID),
-		conds:      os.conds,
-		invariants: os.invariants,
-		ltlRules:   os.ltlRules,
-		labels:     make(map[worldID]map[ConditionName]bool),
+		initial:       initial,
+		worlds:        make(worlds),
+		accessible:    make(map[worldID][]worldID),
+		transitions:   make(map[worldID][]transition),
+		conds:         os.conds,
+		ltlRules:      os.ltlRules,
+		ctlRules:      os.ctlRules,
+		observerRules: os.observerRules,
+		boundedRules:  os.boundedRules,
+		reachRules:    os.reachRules,
+		ghosts:        os.ghosts,
+		labels:        make(map[worldID]map[ConditionName]bool),
+	}
+	m.stepConds = stepConditionOrder(os.conds)
+	for _, name := range os.invariants {
+		if m.isStepCondition(name) {
+			m.stepInvariants = append(m.stepInvariants, name)
+		} else {
+			m.invariants = append(m.invariants, name)
+		}
 	}
 	m.labelWorld(initial)
 	return m, nil
@@ -219,12 +387,22 @@ func (m *model) Solve() error {
 		}
 
 		acc := make([]worldID, 0)
-		nexts, err := stepGlobal(current)
+		kept := make([]transition, 0)
+		nexts, trs, err := stepGlobal(current)
 		if err != nil {
 			return err
 		}
-		for _, next := range nexts {
+		for i, next := range nexts {
+			if trs[i].aborted {
+				m.hasAbortedStep = true
+				m.recordFailures(current, trs[i])
+				continue
+			}
+			next = m.applyGhosts(current, next, &trs[i])
 			acc = append(acc, next.id)
+			m.labelTransition(current, next, &trs[i])
+			m.recordFailures(current, trs[i])
+			kept = append(kept, trs[i])
 			if !m.worlds.member(next) {
 				m.worlds.insert(next)
 				m.labelWorld(next)
@@ -232,11 +410,52 @@ func (m *model) Solve() error {
 			}
 		}
 		m.accessible[current.id] = acc
+		m.transitions[current.id] = kept
 	}
 
 	return nil
 }
 
+// successors returns the worlds reachable in one step from w. Worlds without
+// outgoing transitions are treated as looping on themselves so that every
+// path is infinite.
+func (m *model) successors(w worldID) []worldID {
+	if succs := m.accessible[w]; len(succs) > 0 {
+		return succs
+	}
+	return []worldID{w}
+}
+
+// shortestPaths searches the explored worlds breadth-first from the initial
+// world and returns the predecessor of every reachable world on a shortest
+// path. The initial world is its own predecessor.
+func (m *model) shortestPaths() map[worldID]worldID {
+	pre := map[worldID]worldID{m.initial.id: m.initial.id}
+	queue := []worldID{m.initial.id}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		for _, next := range m.accessible[w] {
+			if _, seen := pre[next]; seen {
+				continue
+			}
+			pre[next] = w
+			queue = append(queue, next)
+		}
+	}
+	return pre
+}
+
+// pathTo returns the path from the initial world to w recorded in pre.
+func pathTo(pre map[worldID]worldID, w worldID) []worldID {
+	path := []worldID{w}
+	for pre[w] != w {
+		w = pre[w]
+		path = append([]worldID{w}, path...)
+	}
+	return path
+}
+
 func (m *model) evaluateInvariants(w world) []ConditionName {
 	failed := make([]ConditionName, 0)
 	for _, name := range m.invariants {
@@ -244,14 +463,21 @@ func (m *model) evaluateInvariants(w world) []ConditionName {
 			failed = append(failed, name)
 		}
 	}
-	return failed
+	return append(failed, m.checkStateInvariants(w)...)
 }
 
 type options struct {
-	sms        []AbstractStateMachine
-	conds      map[ConditionName]Condition
-	invariants []ConditionName
-	ltlRules   []ltlRule
+	sms           []AbstractStateMachine
+	conds         map[ConditionName]Condition
+	invariants    []ConditionName
+	ltlRules      []ltlRule
+	ctlRules      []ctlRule
+	observerRules []observerRule
+	boundedRules  []boundedRule
+	reachRules    []reachRule
+	ghosts        []AbstractGhost
+	// unhandledPolicy applies to machines whose spec sets no policy.
+	unhandledPolicy UnhandledEventPolicy
 }
 
 // Option is a configuration option for model checking operations.
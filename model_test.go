@@ -128,7 +128,7 @@ func TestModel_Solve(t *testing.T) {
 
 				opts := cmp.Options{
 					cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
-					cmpopts.IgnoreFields(model{}, "conds", "invariants", "labels"), // Ignore function pointers and maps
+					cmpopts.IgnoreFields(model{}, "conds", "invariants", "labels", "transitions"), // Ignore function pointers and maps
 					cmp.AllowUnexported(
 						model{},
 						world{},
@@ -436,7 +436,7 @@ func TestStepGlobal(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := tt.setup()
-			got, err := stepGlobal(w)
+			got, _, err := stepGlobal(w)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("stepGlobal() error = %v, wantErr %v", err, tt.wantErr)
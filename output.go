@@ -30,7 +30,7 @@ func (m *model) writeDot(w io.Writer) {
 		sb.WriteString("  ")
 		sb.WriteString(fmt.Sprintf("%d", id))
 		sb.WriteString(` [ label="`)
-		sb.WriteString(wld.label())
+		sb.WriteString(dotEscaper.Replace(wld.label()))
 		sb.WriteString("\" ];\n")
 		if id == m.initial.id {
 			sb.WriteString("  ")
@@ -52,14 +52,18 @@ func (m *model) writeDot(w io.Writer) {
 	sort.Slice(fromIDs, func(i, j int) bool { return fromIDs[i] < fromIDs[j] })
 
 	for _, from := range fromIDs {
-		tos := m.accessible[from]
-		sort.Slice(tos, func(i, j int) bool { return tos[i] < tos[j] })
+		edges := m.dotEdges(from)
 		fromStr := fmt.Sprintf("%d", from)
-		for _, to := range tos {
+		for _, e := range edges {
 			sb.WriteString("  ")
 			sb.WriteString(fromStr)
 			sb.WriteString(" -> ")
-			sb.WriteString(fmt.Sprintf("%d", to))
+			sb.WriteString(fmt.Sprintf("%d", e.to))
+			if e.label != "" {
+				sb.WriteString(` [ label="`)
+				sb.WriteString(dotEscaper.Replace(e.label))
+				sb.WriteString(`" ]`)
+			}
 			sb.WriteString(";\n")
 		}
 	}
@@ -69,16 +73,50 @@ func (m *model) writeDot(w io.Writer) {
 	_, _ = io.WriteString(w, sb.String())
 }
 
+// dotEscaper escapes text written into a quoted DOT string, such as values
+// of string fields and choices.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+type dotEdge struct {
+	to    worldID
+	label string
+}
+
+// dotEdges returns the edges leaving from, labeled with the step taken when
+// it is known, sorted by target and label.
+func (m *model) dotEdges(from worldID) []dotEdge {
+	var edges []dotEdge
+	if trs, ok := m.transitions[from]; ok {
+		for _, tr := range trs {
+			edges = append(edges, dotEdge{to: tr.to, label: tr.describe(m.worlds[tr.to].env)})
+		}
+	} else {
+		for _, to := range m.accessible[from] {
+			edges = append(edges, dotEdge{to: to})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].to != edges[j].to {
+			return edges[i].to < edges[j].to
+		}
+		return edges[i].label < edges[j].label
+	})
+	return edges
+}
+
 func (m *model) writeInvariantViolations(w io.Writer) {
 	var sb strings.Builder
-	violations := m.collectInvariantViolations()
+	violations := append(m.collectInvariantViolations(), m.collectStepViolations()...)
 	for i, violation := range violations {
 		if i > 0 {
 			sb.WriteString("\n")
 		}
 
 		name := violation.condition.String()
-		if name == "" {
+		if violation.failure != nil {
+			sb.WriteString(violation.failure.title())
+			sb.WriteString(".\n")
+		} else if name == "" {
 			sb.WriteString("Condition failed.\n")
 		} else {
 			sb.WriteString("Condition failed. Not Always ")
@@ -86,16 +124,41 @@ func (m *model) writeInvariantViolations(w io.Writer) {
 			sb.WriteString(".\n")
 		}
 
+		labels := m.labels[violation.path[len(violation.path)-1]]
+		if violation.step != nil {
+			labels = violation.step.labels
+		}
+		if values := operandValues(m.conds[violation.condition], labels); len(values) > 0 {
+			sb.WriteString("Subconditions at violation:\n")
+			for _, value := range values {
+				sb.WriteString("  ")
+				sb.WriteString(value)
+				sb.WriteString("\n")
+			}
+		}
+
 		sb.WriteString("Path (length = ")
 		sb.WriteString(fmt.Sprintf("%d", len(violation.path)))
 		sb.WriteString("):\n")
 
 		m.writeWorldSequence(&sb, violation.path, func(idx int, w world) string {
-			if idx == len(violation.path)-1 {
-				return "<-- violation here"
+			if idx != len(violation.path)-1 {
+				return ""
 			}
-			return ""
+			if violation.step != nil && violation.step.aborted {
+				return "<-- failure in next step (" + violation.step.describe(w.env) + ")"
+			}
+			if violation.step != nil {
+				return "<-- violation here (" + violation.step.describe(w.env) + ")"
+			}
+			return "<-- violation here"
 		})
+		if violation.failure != nil {
+			for _, line := range violation.failure.details {
+				sb.WriteString(line)
+				sb.WriteString("\n")
+			}
+		}
 	}
 
 	_, _ = io.WriteString(w, sb.String())
@@ -110,8 +173,7 @@ func (m *model) writeTemporalViolations(w io.Writer, results []temporalRuleResul
 			continue
 		}
 
-		lasso, ok := res.Evidence.(*lasso)
-		if !ok || lasso == nil {
+		if res.Evidence == nil {
 			continue
 		}
 
@@ -129,37 +191,120 @@ func (m *model) writeTemporalViolations(w io.Writer, results []temporalRuleResul
 			sb.WriteString(".\n")
 		}
 
-		prefixLen := len(lasso.Prefix)
-		loopLen := len(lasso.Loop)
-
-		sequence := make([]worldID, 0, prefixLen+loopLen)
-		sequence = append(sequence, lasso.Prefix...)
-		if loopLen > 0 {
-			if prefixLen == 0 || lasso.Prefix[prefixLen-1] != lasso.Loop[0] {
-				sequence = append(sequence, lasso.Loop...)
-			} else {
-				sequence = append(sequence, lasso.Loop[1:]...)
-			}
+		switch evidence := res.Evidence.(type) {
+		case *lasso:
+			m.writeLasso(&sb, evidence)
+		case *ctlTrace:
+			sb.WriteString("Counterexample:\n")
+			m.writeCTLTrace(&sb, evidence, 0)
+		case *boundedTrace:
+			m.writeBoundedTrace(&sb, evidence)
+		case *reachWitness:
+			sb.WriteString("No explored world satisfies ")
+			sb.WriteString(evidence.cond.String())
+			fmt.Fprintf(&sb, " (%d worlds explored).\n", len(m.worlds))
+		case *finitePath:
+			sb.WriteString("Violation path (length = ")
+			fmt.Fprintf(&sb, "%d", len(evidence.Path))
+			sb.WriteString("):\n")
+			m.writeWorldSequence(&sb, evidence.Path, func(idx int, _ world) string {
+				if idx == len(evidence.Path)-1 {
+					return "<-- violation here"
+				}
+				return ""
+			})
 		}
+	}
 
-		if len(sequence) == 0 {
-			sb.WriteString("Violation path (length = 0):\n")
-			sb.WriteString("  <empty witness>\n")
-			continue
-		}
+	if block == 0 {
+		return
+	}
 
-		sb.WriteString("Violation path (length = ")
-		sb.WriteString(fmt.Sprintf("%d", len(sequence)))
-		sb.WriteString("):\n")
+	_, _ = io.WriteString(w, sb.String())
+}
+
+func (m *model) writeLasso(sb *strings.Builder, lasso *lasso) {
+	prefixLen := len(lasso.Prefix)
+	loopLen := len(lasso.Loop)
 
-		m.writeWorldSequence(&sb, sequence, nil)
+	sequence := make([]worldID, 0, prefixLen+loopLen)
+	sequence = append(sequence, lasso.Prefix...)
+	if loopLen > 0 {
+		if prefixLen == 0 || lasso.Prefix[prefixLen-1] != lasso.Loop[0] {
+			sequence = append(sequence, lasso.Loop...)
+		} else {
+			sequence = append(sequence, lasso.Loop[1:]...)
+		}
 	}
 
-	if block == 0 {
+	if len(sequence) == 0 {
+		sb.WriteString("Violation path (length = 0):\n")
+		sb.WriteString("  <empty witness>\n")
 		return
 	}
 
-	_, _ = io.WriteString(w, sb.String())
+	sb.WriteString("Violation path (length = ")
+	sb.WriteString(fmt.Sprintf("%d", len(sequence)))
+	sb.WriteString("):\n")
+
+	m.writeWorldSequence(sb, sequence, nil)
+}
+
+func (m *model) writeBoundedTrace(sb *strings.Builder, t *boundedTrace) {
+	sb.WriteString("Longest observed distance: ")
+	if t.Unbounded {
+		sb.WriteString("unbounded")
+	} else {
+		fmt.Fprintf(sb, "%d steps", t.Longest)
+	}
+	fmt.Fprintf(sb, " (bound = %d)\n", t.Bound)
+	sb.WriteString("Violation path (length = ")
+	fmt.Fprintf(sb, "%d", len(t.Path))
+	sb.WriteString("):\n")
+	m.writeWorldSequence(sb, t.Path, func(idx int, _ world) string {
+		var notes []string
+		if idx == t.Trigger {
+			notes = append(notes, t.trigger.String()+" holds")
+		}
+		if idx == len(t.Path)-1 {
+			if t.Unbounded {
+				notes = append(notes, "loops or stops before "+t.reached)
+			} else {
+				notes = append(notes, t.reached)
+			}
+		}
+		if len(notes) == 0 {
+			return ""
+		}
+		return "<-- " + strings.Join(notes, ", ")
+	})
+}
+
+func (m *model) writeCTLTrace(sb *strings.Builder, t *ctlTrace, depth int) {
+	indent := strings.Repeat("  ", depth)
+	sb.WriteString(indent)
+	sb.WriteString("- ")
+	sb.WriteString(t.Formula)
+	if t.Holds {
+		sb.WriteString(" holds\n")
+	} else {
+		sb.WriteString(" does not hold\n")
+	}
+	if len(t.Path) > 0 {
+		sb.WriteString("Path (length = ")
+		fmt.Fprintf(sb, "%d", len(t.Path))
+		sb.WriteString("):\n")
+		m.writeWorldSequence(sb, t.Path, nil)
+	}
+	if len(t.Loop) > 0 {
+		sb.WriteString("Loop (length = ")
+		fmt.Fprintf(sb, "%d", len(t.Loop))
+		sb.WriteString("):\n")
+		m.writeWorldSequence(sb, t.Loop, nil)
+	}
+	for _, child := range t.Children {
+		m.writeCTLTrace(sb, child, depth+1)
+	}
 }
 
 func (m *model) writeWorldSequence(sb *strings.Builder, worldIDs []worldID, annotate func(int, world) string) {
@@ -183,7 +328,7 @@ func (m *model) writeWorldSequence(sb *strings.Builder, worldIDs []worldID, anno
 			sb.WriteString(", Detail: ")
 			sb.WriteString(getStateMachineDetails(sm))
 			sb.WriteString(", State: ")
-			sb.WriteString(getStateDetails(sm.currentState()))
+			sb.WriteString(describeState(sm))
 			sb.WriteString("\n")
 		}
 		sb.WriteString("  QueuedEvents:\n")
@@ -198,12 +343,25 @@ func (m *model) writeWorldSequence(sb *strings.Builder, worldIDs []worldID, anno
 				sb.WriteString("\n")
 			}
 		}
+		if ghosts := ghostLines(world.env); len(ghosts) > 0 {
+			sb.WriteString("  Ghosts:\n")
+			for _, line := range ghosts {
+				sb.WriteString("    ")
+				sb.WriteString(line)
+				sb.WriteString("\n")
+			}
+		}
 	}
 }
 
 type invariantViolationWitness struct {
 	path      []worldID
 	condition ConditionName
+	// step is the transition into the last world of path when the violated
+	// invariant is a step condition.
+	step *transition
+	// failure is the problem reported by a handler, if any.
+	failure *handlerFailure
 }
 
 func (m *model) collectInvariantViolations() []invariantViolationWitness {
@@ -288,7 +446,7 @@ func (w world) label() string {
 	})
 	for _, name := range smIDs {
 		sm := w.env.machines[name]
-		strs = append(strs, fmt.Sprintf("%s = %s; State: %s", getStateMachineName(sm), getStateMachineDetails(sm), getStateDetails(sm.currentState())))
+		strs = append(strs, fmt.Sprintf("%s = %s; State: %s", getStateMachineName(sm), getStateMachineDetails(sm), describeState(sm)))
 	}
 
 	strs = append(strs, "\nQueuedEvents:")
@@ -307,6 +465,10 @@ func (w world) label() string {
 			}
 		}
 	}
+	if ghosts := ghostLines(w.env); len(ghosts) > 0 {
+		strs = append(strs, "\nGhosts:")
+		strs = append(strs, ghosts...)
+	}
 	return strings.Join(strs, "\n")
 }
 
@@ -314,6 +476,7 @@ type worldJSON struct {
 	InvariantViolation bool               `json:"invariant_violation"`
 	StateMachines      []stateMachineJSON `json:"state_machines"`
 	QueuedEvents       []eventJSON        `json:"queued_events"`
+	Ghosts             []string           `json:"ghosts,omitempty"`
 }
 
 type stateMachineJSON struct {
@@ -329,6 +492,36 @@ type eventJSON struct {
 	Details       string `json:"details"`
 }
 
+type stepViolationJSON struct {
+	Kind      string `json:"kind"`
+	MachineID string `json:"machine_id"`
+	Event     string `json:"event,omitempty"`
+	Message   string `json:"message"`
+}
+
+// stepViolationsToJSON lists the violations found on steps, such as handler
+// failures and broken step invariants, as reported by Test.
+func (m *model) stepViolationsToJSON() []stepViolationJSON {
+	var violations []stepViolationJSON
+	for _, v := range m.collectStepViolations() {
+		kind, message := "Step invariant violated", v.condition.String()
+		if v.failure != nil {
+			kind, message = v.failure.kind, v.failure.message
+		}
+		var event string
+		if v.step.event != nil {
+			event = getEventName(v.step.event)
+		}
+		violations = append(violations, stepViolationJSON{
+			Kind:      kind,
+			MachineID: v.step.smID,
+			Event:     event,
+			Message:   message,
+		})
+	}
+	return violations
+}
+
 func (m *model) worldsToJSON() []worldJSON {
 	allWorlds := make([]worldJSON, 0, len(m.worlds))
 	for _, world := range m.worlds {
@@ -390,7 +583,7 @@ func (*model) worldToJSON(w world) worldJSON {
 		stateMachines = append(stateMachines, stateMachineJSON{
 			ID:      smID,
 			Name:    getStateMachineName(sm),
-			State:   getStateDetails(sm.currentState()),
+			State:   describeState(sm),
 			Details: getStateMachineDetails(sm),
 		})
 	}
@@ -422,6 +615,7 @@ func (*model) worldToJSON(w world) worldJSON {
 		InvariantViolation: len(w.failedInvariants) > 0,
 		StateMachines:      stateMachines,
 		QueuedEvents:       queuedEvents,
+		Ghosts:             ghostLines(w.env),
 	}
 }
 
@@ -3,6 +3,7 @@ package goat
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -33,7 +34,7 @@ testStateMachine = no fields; State: {Name:Name,Type:string,Value:initial}
 QueuedEvents:
 testStateMachine << entryEvent;" ];
   8682599965454615616 [ penwidth=5 ];
-  8682599965454615616 -> 5438153399123815847;
+  8682599965454615616 -> 5438153399123815847 [ label="testStateMachine: entryEvent" ];
 }
 `,
 		},
@@ -62,7 +63,7 @@ QueuedEvents:
 testStateMachine << entryEvent;" ];
   8682599965454615616 [ penwidth=5 ];
   8682599965454615616 [ color=red, penwidth=3 ];
-  8682599965454615616 -> 5438153399123815847;
+  8682599965454615616 -> 5438153399123815847 [ label="testStateMachine: entryEvent" ];
 }
 `,
 		},
@@ -101,10 +102,10 @@ QueuedEvents:
 testStateMachine << entryEvent;
 testStateMachine << entryEvent;" ];
   18043829544564786018 [ penwidth=5 ];
-  1352120299877738753 -> 8000304505176841628;
-  10115204962392696257 -> 8000304505176841628;
-  18043829544564786018 -> 1352120299877738753;
-  18043829544564786018 -> 10115204962392696257;
+  1352120299877738753 -> 8000304505176841628 [ label="testStateMachine: entryEvent" ];
+  10115204962392696257 -> 8000304505176841628 [ label="testStateMachine: entryEvent" ];
+  18043829544564786018 -> 1352120299877738753 [ label="testStateMachine: entryEvent" ];
+  18043829544564786018 -> 10115204962392696257 [ label="testStateMachine: entryEvent" ];
 }
 `,
 		},
@@ -610,6 +611,47 @@ func TestModel_worldsToJSON(t *testing.T) {
 	}
 }
 
+func TestDebug_stepViolations(t *testing.T) {
+	type mapStateMachine struct {
+		StateMachine
+		Seen map[int]bool
+	}
+
+	spec := NewStateMachineSpec(&mapStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, sm *mapStateMachine) {
+		SendTo(ctx, sm, &testEvent{Value: 1})
+	})
+	OnEvent(spec, idle, func(ctx context.Context, e *testEvent, sm *mapStateMachine) {
+		Assert(ctx, e.Value == 0, "value must be zero")
+		sm.Seen[e.Value] = true
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Debug(&buf, WithStateMachines(sm)); err != nil {
+		t.Fatalf("Debug error: %v", err)
+	}
+	var result struct {
+		StepViolations []stepViolationJSON `json:"step_violations"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	want := []stepViolationJSON{
+		{Kind: failureAssertion, MachineID: sm.id(), Event: "testEvent", Message: "value must be zero"},
+		{Kind: failurePanic, MachineID: sm.id(), Event: "testEvent", Message: "assignment to entry in nil map"},
+	}
+	if diff := cmp.Diff(want, result.StepViolations); diff != "" {
+		t.Errorf("step violations mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestModel_summarize(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -0,0 +1,124 @@
+package goat
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reachable returns a rule enforcing that some explored world satisfies c.
+// Unlike the other rules it is existential: it guards against models that
+// pass vacuously because the interesting scenario can never happen.
+//
+// When c is reached, Test prints a shortest witness path leading to it. When
+// c is a step condition, the witness ends with a step on which it holds.
+//
+// Parameters:
+//   - c: Condition that must hold somewhere in the explored state space
+//
+// Returns a Rule that can be registered with WithRules.
+//
+// Example:
+//
+//	err := goat.Test(
+//		goat.WithStateMachines(client, server),
+//		goat.WithRules(goat.Reachable(reserved)),
+//	)
+func Reachable(c Condition) Rule {
+	return ruleFunc(func(o *options) {
+		registerCondition(o, c)
+		o.reachRules = append(o.reachRules, reachRule{c: c.Name()})
+	})
+}
+
+// Sometimes is an alias of Reachable.
+func Sometimes(c Condition) Rule {
+	return Reachable(c)
+}
+
+type reachRule struct {
+	c ConditionName
+}
+
+func (r reachRule) name() string { return fmt.Sprintf("reachable %s", r.c) }
+
+// reachWitness is a shortest path from the initial world to a world
+// satisfying the condition of a reachability rule. Path is empty when no
+// explored world satisfies it.
+type reachWitness struct {
+	Path []worldID `json:"path,omitempty"`
+
+	cond ConditionName
+}
+
+func (*reachWitness) temporalEvidence() {}
+
+func (m *model) checkReachability() []temporalRuleResult {
+	results := make([]temporalRuleResult, 0, len(m.reachRules))
+	for _, r := range m.reachRules {
+		witness := &reachWitness{Path: m.findWitness(r.c), cond: r.c}
+		result := temporalRuleResult{Rule: r.name(), Satisfied: len(witness.Path) > 0, Evidence: witness}
+		if !result.Satisfied {
+			m.hasTemporalViolation = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// findWitness searches the explored worlds breadth-first for the first world,
+// or step for step conditions, where c holds.
+func (m *model) findWitness(c ConditionName) []worldID {
+	onStep := m.isStepCondition(c)
+	pre := map[worldID]worldID{m.initial.id: m.initial.id}
+	queue := []worldID{m.initial.id}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		if !onStep && m.labels[w][c] {
+			return pathTo(pre, w)
+		}
+		for _, e := range m.outgoing(w) {
+			if onStep && e.labels[c] {
+				return append(pathTo(pre, w), e.to)
+			}
+			if _, seen := pre[e.to]; seen {
+				continue
+			}
+			pre[e.to] = w
+			queue = append(queue, e.to)
+		}
+	}
+	return nil
+}
+
+// writeWitnesses prints the witness paths of the satisfied reachability
+// rules and existential CTL rules.
+func (m *model) writeWitnesses(w io.Writer, results []temporalRuleResult) {
+	var sb strings.Builder
+	for _, res := range results {
+		if !res.Satisfied || res.Evidence == nil {
+			continue
+		}
+		switch evidence := res.Evidence.(type) {
+		case *reachWitness:
+			sb.WriteString("\nWitness for ")
+			sb.WriteString(res.Rule)
+			sb.WriteString(".\nPath (length = ")
+			fmt.Fprintf(&sb, "%d", len(evidence.Path))
+			sb.WriteString("):\n")
+			m.writeWorldSequence(&sb, evidence.Path, func(idx int, _ world) string {
+				if idx == len(evidence.Path)-1 {
+					return "<-- " + evidence.cond.String() + " holds"
+				}
+				return ""
+			})
+		case *ctlTrace:
+			sb.WriteString("\nWitness for ")
+			sb.WriteString(res.Rule)
+			sb.WriteString(".\n")
+			m.writeCTLTrace(&sb, evidence, 0)
+		}
+	}
+	_, _ = io.WriteString(w, sb.String())
+}
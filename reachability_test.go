@@ -0,0 +1,90 @@
+package goat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReachable(t *testing.T) {
+	tests := []struct {
+		name    string
+		cond    func(sm *testStateMachine, inB Condition) Condition
+		want    bool
+		wantLen int
+	}{
+		{
+			name:    "world condition reached",
+			cond:    func(_ *testStateMachine, inB Condition) Condition { return inB },
+			want:    true,
+			wantLen: 4,
+		},
+		{
+			name: "step condition reached",
+			cond: func(sm *testStateMachine, _ Condition) Condition {
+				return EventHandled[*transitionEvent]("moved", sm, nil)
+			},
+			want:    true,
+			wantLen: 4,
+		},
+		{
+			name: "unreachable condition",
+			cond: func(_ *testStateMachine, _ Condition) Condition { return BoolCondition("never", false) },
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, _, inB := newTwoStateTestMachine(false)
+			c := tt.cond(sm, inB)
+			m := newSolvedTestModel(t,
+				WithStateMachines(sm),
+				WithRules(Sometimes(c)),
+			)
+			res := m.checkReachability()
+			if len(res) != 1 {
+				t.Fatalf("expected one result, got %d", len(res))
+			}
+			if res[0].Satisfied != tt.want {
+				t.Fatalf("Satisfied = %v, want %v", res[0].Satisfied, tt.want)
+			}
+			if m.hasTemporalViolation == tt.want {
+				t.Errorf("hasTemporalViolation = %v, want %v", m.hasTemporalViolation, !tt.want)
+			}
+			witness := res[0].Evidence.(*reachWitness)
+			if len(witness.Path) != tt.wantLen {
+				t.Fatalf("witness length = %d, want %d", len(witness.Path), tt.wantLen)
+			}
+			if tt.want && witness.Path[0] != m.initial.id {
+				t.Errorf("witness should start at the initial world")
+			}
+		})
+	}
+}
+
+func TestReachable_output(t *testing.T) {
+	sm, _, inB := newTwoStateTestMachine(false)
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithRules(Reachable(inB), Reachable(BoolCondition("never", false))),
+	)
+	res := m.checkTemporalRules()
+
+	var violations bytes.Buffer
+	m.writeTemporalViolations(&violations, res)
+	want := "Condition failed. Not reachable never.\nNo explored world satisfies never (5 worlds explored).\n"
+	if got := violations.String(); got != want {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+
+	var witnesses bytes.Buffer
+	m.writeWitnesses(&witnesses, res)
+	got := witnesses.String()
+	if !strings.HasPrefix(got, "\nWitness for reachable inB.\nPath (length = 4):\n") {
+		t.Errorf("unexpected witness output:\n%s", got)
+	}
+	if !strings.Contains(got, "  [3] <-- inB holds\n") {
+		t.Errorf("expected witness to end where inB holds:\n%s", got)
+	}
+}
@@ -0,0 +1,185 @@
+package goat
+
+import (
+	"fmt"
+	"strings"
+)
+
+type region struct {
+	name   string
+	states []AbstractState
+	// current is the active state of the region. It is copied when the
+	// machine is cloned.
+	current AbstractState
+}
+
+// regionTransitionEvent switches a region to another state.
+type regionTransitionEvent struct {
+	UnTypedEvent
+	Region string
+	To     AbstractState
+}
+
+// DefineRegion adds an orthogonal region to the spec: an independent set of
+// states with its own current state, active alongside the machine's main
+// states. The first state is the region's initial state.
+//
+// Events taken from the queue are dispatched to the main state and then to
+// each region, in the order the regions were defined; every handler that
+// matches runs as part of the same step. Handlers for region states are
+// registered with OnEvent, OnEntry and OnExit like any other state, and Goto
+// with a region state switches that region only. Use RegionState to read a
+// region's current state from conditions.
+//
+// Parameters:
+//   - name: The region name, unique within the spec
+//   - states: The region's states, the first one being its initial state
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	spec.DefineStates(disconnected, connected).SetInitialState(disconnected).
+//	    DefineRegion("auth", loggedOut, loggedIn)
+//	goat.OnEvent(spec, loggedOut, func(ctx context.Context, e *LoginEvent, sm *Client) {
+//	    goat.Goto(ctx, loggedIn)
+//	})
+func (spec *StateMachineSpec[T]) DefineRegion(name string, states ...AbstractState) *StateMachineSpec[T] {
+	r := region{name: name, states: states}
+	if len(states) > 0 {
+		r.current = states[0]
+	}
+	spec.regions = append(spec.regions, r)
+	return spec
+}
+
+func (spec *StateMachineSpec[T]) validateRegions() error {
+	for i, r := range spec.regions {
+		if len(r.states) == 0 {
+			return fmt.Errorf("region %s has no states", r.name)
+		}
+		for _, other := range spec.regions[:i] {
+			if other.name == r.name {
+				return fmt.Errorf("region %s is defined more than once", r.name)
+			}
+		}
+	}
+	return nil
+}
+
+// RegionState returns the current state of the named region of sm, or nil
+// when sm has no such region. Every world holds its own copy of the state,
+// so compare it by its fields rather than with == against the state passed
+// to DefineRegion.
+//
+// Parameters:
+//   - sm: The state machine
+//   - name: The region name given to DefineRegion
+//
+// Example:
+//
+//	loggedInCond := goat.NewCondition("logged in", client, func(sm *Client) bool {
+//	    return goat.RegionState(sm, "auth").(*AuthState).Name == "loggedIn"
+//	})
+func RegionState(sm AbstractStateMachine, name string) AbstractState {
+	for _, r := range getInnerStateMachine(sm).regions {
+		if r.name == name {
+			return r.current
+		}
+	}
+	return nil
+}
+
+// regionOf returns the index of the region containing state, or -1 when
+// state is one of the main states.
+func (sm *StateMachine) regionOf(state AbstractState) int {
+	for i, r := range sm.regions {
+		for _, s := range r.states {
+			if sameState(s, state) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func cloneRegions(regions []region) []region {
+	if regions == nil {
+		return nil
+	}
+	cloned := make([]region, len(regions))
+	for i, r := range regions {
+		cloned[i] = r
+		cloned[i].current = cloneState(r.current)
+	}
+	return cloned
+}
+
+// gotoRegion queues the events switching region i of sm to state.
+func gotoRegion(env *environment, sm AbstractStateMachine, i int, state AbstractState) {
+	r := getInnerStateMachine(sm).regions[i]
+	env.enqueueEvent(sm, &exitStateEvent{State: r.current})
+	env.enqueueEvent(sm, &regionTransitionEvent{Region: r.name, To: state})
+	env.enqueueEvent(sm, &enterStateEvent{State: state})
+}
+
+// dispatchToRegions runs the handlers of each region of smID for event on
+// every local state in lss, in region order. A region without a matching
+// handler leaves the local state unchanged. It reports whether any region
+// handled the event.
+func dispatchToRegions(lss []localState, smID string, event AbstractEvent) ([]localState, bool, error) {
+	handled := false
+	n := len(getInnerStateMachine(lss[0].env.machines[smID]).regions)
+	for i := range n {
+		next := make([]localState, 0, len(lss))
+		for _, ls := range lss {
+			if ls.env.step != nil && ls.env.step.aborted {
+				next = append(next, ls)
+				continue
+			}
+			innerSM := getInnerStateMachine(ls.env.machines[smID])
+			his := handlersFor(innerSM, innerSM.regions[i].current)
+			out, err := runMostSpecific(ls.env, smID, his, event, event, func(handler) bool { return true })
+			if err != nil {
+				return nil, false, err
+			}
+			if len(out) == 0 {
+				next = append(next, ls)
+				continue
+			}
+			handled = true
+			for _, o := range out {
+				o.env.step = mergeSteps(ls.env.step, o.env.step)
+				next = append(next, o)
+			}
+		}
+		lss = next
+	}
+	return lss, handled, nil
+}
+
+// mergeSteps combines the records of two handlers run one after the other
+// during the same step.
+func mergeSteps(first, second *stepRecord) *stepRecord {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	return &stepRecord{
+		sent:     append(append([]sentEvent(nil), first.sent...), second.sent...),
+		failures: append(append([]handlerFailure(nil), first.failures...), second.failures...),
+		aborted:  first.aborted || second.aborted,
+		choices:  append(append([]choiceRecord(nil), first.choices...), second.choices...),
+	}
+}
+
+// describeRegions returns the current state of each region of sm.
+func describeRegions(sm *StateMachine) string {
+	parts := make([]string, len(sm.regions))
+	for i, r := range sm.regions {
+		parts[i] = r.name + "=" + getStateDetails(r.current)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,124 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestStateMachineSpec_DefineRegion(t *testing.T) {
+	type clientStateMachine struct {
+		StateMachine
+		Logins int
+	}
+
+	spec := NewStateMachineSpec(&clientStateMachine{})
+	disconnected := newTestState("disconnected")
+	connected := newTestState("connected")
+	loggedOut := newTestState("loggedOut")
+	loggedIn := newTestState("loggedIn")
+	spec.
+		DefineStates(disconnected, connected).
+		SetInitialState(disconnected).
+		DefineRegion("auth", loggedOut, loggedIn).
+		SetUnhandledEventPolicy(ReportUnhandledEvents)
+	OnEntry(spec, disconnected, func(ctx context.Context, sm *clientStateMachine) {
+		SendTo(ctx, sm, &testEvent{Value: 1})
+	})
+	OnEvent(spec, disconnected, func(ctx context.Context, _ *testEvent, _ *clientStateMachine) {
+		Goto(ctx, connected)
+	})
+	OnEvent(spec, loggedOut, func(ctx context.Context, _ *testEvent, _ *clientStateMachine) {
+		Goto(ctx, loggedIn)
+	})
+	OnEntry(spec, loggedIn, func(_ context.Context, sm *clientStateMachine) {
+		sm.Logins++
+	})
+
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+	if got := RegionState(sm, "auth"); !sameState(got, loggedOut) {
+		t.Fatalf("RegionState() = %v, want loggedOut", got)
+	}
+	if got := RegionState(sm, "missing"); got != nil {
+		t.Errorf("RegionState() of unknown region = %v, want nil", got)
+	}
+
+	clone := cloneStateMachine(sm)
+	getInnerStateMachine(clone).regions[0].current = loggedIn
+	if !sameState(RegionState(sm, "auth"), loggedOut) {
+		t.Error("changing the region of a clone changed the original")
+	}
+
+	// Conditions compare region states by their fields, as documented on
+	// RegionState: the states of a world are copies.
+	loggedInCond := NewCondition("logged in", sm, func(c *clientStateMachine) bool {
+		return RegionState(c, "auth").(*testState).Name == "loggedIn"
+	})
+	loggedInAfterLogin := NewCondition("logged in after login", sm, func(c *clientStateMachine) bool {
+		return c.Logins == 0 || RegionState(c, "auth").(*testState).Name == "loggedIn"
+	})
+	m := newSolvedTestModel(t,
+		WithStateMachines(sm),
+		WithRules(Always(loggedInAfterLogin), Reachable(loggedInCond)),
+	)
+	if m.hasInvariantViolation {
+		var buf bytes.Buffer
+		m.writeInvariantViolations(&buf)
+		t.Errorf("expected no violation:\n%s", buf.String())
+	}
+	if res := m.checkReachability(); !res[0].Satisfied {
+		t.Error("expected the logged in region state to be reachable")
+	}
+
+	terminal := 0
+	for id, w := range m.worlds {
+		if len(m.accessible[id]) > 0 {
+			continue
+		}
+		terminal++
+		final := w.env.machines[sm.id()].(*clientStateMachine)
+		if !sameState(final.currentState(), connected) || !sameState(RegionState(final, "auth"), loggedIn) {
+			t.Errorf("final states = %s, want connected and loggedIn", describeState(final))
+		}
+		if final.Logins != 1 {
+			t.Errorf("Logins = %d, want 1", final.Logins)
+		}
+	}
+	if terminal != 1 {
+		t.Errorf("got %d terminal worlds, want 1", terminal)
+	}
+}
+
+func TestStateMachineSpec_validateRegions(t *testing.T) {
+	a, b := newTestState("a"), newTestState("b")
+	tests := []struct {
+		name    string
+		define  func(spec *StateMachineSpec[*testStateMachine])
+		wantErr bool
+	}{
+		{name: "valid", define: func(spec *StateMachineSpec[*testStateMachine]) { spec.DefineRegion("r", b) }},
+		{name: "no states", define: func(spec *StateMachineSpec[*testStateMachine]) { spec.DefineRegion("r") }, wantErr: true},
+		{
+			name: "duplicate name",
+			define: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineRegion("r", b).DefineRegion("r", b)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&testStateMachine{})
+			spec.DefineStates(a).SetInitialState(a)
+			tt.define(spec)
+			_, err := spec.NewInstance()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
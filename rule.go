@@ -57,6 +57,9 @@ func WithRules(rs ...Rule) Option {
 func Always(c Condition) Rule {
 	return ruleFunc(func(o *options) {
 		registerCondition(o, c)
+		if ac, ok := c.(antecedentCondition); ok && ac.antecedent() != nil {
+			registerCondition(o, ac.antecedent())
+		}
 		o.invariants = append(o.invariants, c.Name())
 	})
 }
@@ -69,6 +72,11 @@ func registerCondition(o *options, c Condition) {
 		o.conds = make(map[ConditionName]Condition)
 	}
 	o.conds[c.Name()] = c
+	if comb, ok := c.(labelCombinator); ok {
+		for _, operand := range comb.operands() {
+			registerCondition(o, operand)
+		}
+	}
 }
 
 func registerTemporalRule(o *options, rule ltlRule) {
@@ -0,0 +1,101 @@
+package goat
+
+import (
+	"context"
+	"strconv"
+)
+
+// Spawn creates a new instance of spec and adds it to the running system.
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+//
+// The new machine is named like the machines passed to WithStateMachines: its
+// type name, followed by _1, _2 and so on when the name is taken, using the
+// lowest free suffix. IDs therefore only depend on the machines present when
+// Spawn is called, so equivalent worlds reached along different paths are
+// identified, and models that keep spawning and terminating machines have a
+// finite state space. The ID of a terminated machine can be given to a new
+// one, but a reference kept to the terminated machine does not reach it. The
+// machine enters its initial state in its own later steps, like the machines
+// present from the start.
+//
+// Conditions built with NewCondition refer to the machines passed to
+// WithStateMachines; use ForAll, Exists or Count to check spawned machines.
+// The state invariants of spec are checked for spawned machines too.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - spec: The specification of the machine to create
+//   - init: Optional function that initializes the fields of the new
+//     machine before it is added; may be nil
+//
+// Returns the new machine, which can be used as a SendTo target. When spec is
+// invalid, the error is reported as a violation of the step and the zero
+// value is returned.
+//
+// Example:
+//
+//	goat.OnEvent(spec, &Serving{}, func(ctx context.Context, e *RequestEvent, sm *Server) {
+//	    worker := goat.Spawn(ctx, workerSpec, func(w *Worker) { w.RequestID = e.ID })
+//	    goat.SendTo(ctx, worker, &StartEvent{})
+//	})
+func Spawn[SM AbstractStateMachine](ctx context.Context, spec *StateMachineSpec[SM], init func(SM)) SM {
+	env := getEnvFromContext(ctx)
+	sm, err := spec.NewInstance()
+	if err != nil {
+		failOnError(ctx, err)
+		return sm
+	}
+	if init != nil {
+		init(sm)
+	}
+
+	baseName := sm.id()
+	smID := baseName
+	for n := 1; env.machines[smID] != nil; n++ {
+		smID = baseName + "_" + strconv.Itoa(n)
+	}
+	installHandlers(sm, smID)
+	getInnerStateMachine(sm).incarnation = &incarnation{smID: smID}
+	resolveUnhandledPolicy(sm, env.unhandledPolicy)
+	env.machines[smID] = sm
+	env.queue[smID] = initialEvents(sm)
+	return sm
+}
+
+// Terminate stops the current state machine and removes it from the system
+// at the end of the step, together with the events left in its queue. Unlike
+// Halt, no handler runs and the machine no longer appears in later worlds;
+// events sent to it afterwards are dropped, even once Spawn has given its ID
+// to a new machine.
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//
+// Example:
+//
+//	goat.OnEvent(spec, &Working{}, func(ctx context.Context, e *DoneEvent, sm *Worker) {
+//	    goat.SendTo(ctx, sm.Server, &ResultEvent{ID: sm.RequestID})
+//	    goat.Terminate(ctx)
+//	})
+func Terminate(ctx context.Context) {
+	sm := getSMFromContext(ctx)
+	getInnerStateMachine(sm).terminated = true
+}
+
+// incarnation identifies a machine created by Spawn.
+type incarnation struct {
+	smID string
+}
+
+// removeTerminated removes the machines that called Terminate from env.
+func (e *environment) removeTerminated() {
+	for smID, sm := range e.machines {
+		if getInnerStateMachine(sm).terminated {
+			delete(e.machines, smID)
+			delete(e.queue, smID)
+		}
+	}
+}
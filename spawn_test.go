@@ -0,0 +1,327 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type spawnServerStateMachine struct {
+	StateMachine
+	Results int
+}
+
+type spawnWorkerStateMachine struct {
+	StateMachine
+	Server    *spawnServerStateMachine
+	RequestID int
+}
+
+func TestSpawn(t *testing.T) {
+	workerSpec := NewStateMachineSpec(&spawnWorkerStateMachine{})
+	working := newTestState("working")
+	workerSpec.DefineStates(working).SetInitialState(working)
+	OnEntry(workerSpec, working, func(ctx context.Context, sm *spawnWorkerStateMachine) {
+		SendTo(ctx, sm.Server, &testEvent{Value: sm.RequestID})
+		Terminate(ctx)
+	})
+
+	serverSpec := NewStateMachineSpec(&spawnServerStateMachine{})
+	serving := newTestState("serving")
+	serverSpec.DefineStates(serving).SetInitialState(serving)
+	var spawned []string
+	OnEntry(serverSpec, serving, func(ctx context.Context, sm *spawnServerStateMachine) {
+		for i := range 2 {
+			worker := Spawn(ctx, workerSpec, func(w *spawnWorkerStateMachine) {
+				w.Server = sm
+				w.RequestID = i + 1
+			})
+			spawned = append(spawned, worker.id())
+		}
+	})
+	OnEvent(serverSpec, serving, func(_ context.Context, _ *testEvent, sm *spawnServerStateMachine) {
+		sm.Results++
+	})
+	server, err := serverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	m := newSolvedTestModel(t, WithStateMachines(server))
+
+	if diff := cmp.Diff([]string{"spawnWorkerStateMachine", "spawnWorkerStateMachine_1"}, spawned); diff != "" {
+		t.Errorf("spawned IDs mismatch (-want +got):\n%s", diff)
+	}
+	terminal := 0
+	for id, w := range m.worlds {
+		if len(m.accessible[id]) > 0 {
+			continue
+		}
+		terminal++
+		var ids []string
+		for smID := range w.env.machines {
+			ids = append(ids, smID)
+		}
+		if diff := cmp.Diff([]string{server.id()}, ids); diff != "" {
+			t.Errorf("machines in final world mismatch (-want +got):\n%s", diff)
+		}
+		if got := w.env.machines[server.id()].(*spawnServerStateMachine).Results; got != 2 {
+			t.Errorf("Results = %d, want 2", got)
+		}
+	}
+	if terminal != 1 {
+		t.Errorf("got %d terminal worlds, want 1", terminal)
+	}
+}
+
+type staleWorkerStateMachine struct {
+	StateMachine
+	Server    *staleServerStateMachine
+	First     bool
+	Delivered int
+}
+
+type staleServerStateMachine struct {
+	StateMachine
+	First  *staleWorkerStateMachine
+	Second *staleWorkerStateMachine
+}
+
+func TestSpawn_staleReference(t *testing.T) {
+	workerSpec := NewStateMachineSpec(&staleWorkerStateMachine{})
+	working := newTestState("working")
+	workerSpec.DefineStates(working).SetInitialState(working)
+	OnEntry(workerSpec, working, func(ctx context.Context, sm *staleWorkerStateMachine) {
+		if sm.First {
+			SendTo(ctx, sm.Server, &testEvent{})
+			Terminate(ctx)
+		}
+	})
+	OnEvent(workerSpec, working, func(_ context.Context, _ *genericTestEvent[int], sm *staleWorkerStateMachine) {
+		sm.Delivered++
+	})
+
+	serverSpec := NewStateMachineSpec(&staleServerStateMachine{})
+	serving := newTestState("serving")
+	serverSpec.DefineStates(serving).SetInitialState(serving)
+	OnEntry(serverSpec, serving, func(ctx context.Context, sm *staleServerStateMachine) {
+		sm.First = Spawn(ctx, workerSpec, func(w *staleWorkerStateMachine) {
+			w.Server = sm
+			w.First = true
+		})
+	})
+	// The first worker has terminated when its result arrives, so the second
+	// one is given its ID. It must not receive what is sent to the first one.
+	OnEvent(serverSpec, serving, func(ctx context.Context, _ *testEvent, sm *staleServerStateMachine) {
+		sm.Second = Spawn(ctx, workerSpec, nil)
+		SendTo(ctx, sm.First, &genericTestEvent[int]{Payload: 1})
+	})
+	server, err := serverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	m := newSolvedTestModel(t, WithStateMachines(server))
+
+	secondSpawned := false
+	for _, w := range m.worlds {
+		s := w.env.machines[server.id()].(*staleServerStateMachine)
+		if s.Second == nil {
+			continue
+		}
+		secondSpawned = true
+		if s.Second.id() != s.First.id() {
+			t.Fatalf("second worker ID = %s, want the ID %s of the terminated one", s.Second.id(), s.First.id())
+		}
+		if second, ok := w.env.machines[s.Second.id()].(*staleWorkerStateMachine); ok && second.Delivered != 0 {
+			t.Errorf("second worker received %d events sent to the first one", second.Delivered)
+		}
+	}
+	if !secondSpawned {
+		t.Error("second worker was never spawned")
+	}
+}
+
+type cyclicServerStateMachine struct {
+	StateMachine
+}
+
+type cyclicWorkerStateMachine struct {
+	StateMachine
+	Server *cyclicServerStateMachine
+}
+
+func TestSpawn_cyclic(t *testing.T) {
+	workerSpec := NewStateMachineSpec(&cyclicWorkerStateMachine{})
+	working := newTestState("working")
+	workerSpec.DefineStates(working).SetInitialState(working)
+	OnEntry(workerSpec, working, func(ctx context.Context, sm *cyclicWorkerStateMachine) {
+		SendTo(ctx, sm.Server, &testEvent{})
+		Terminate(ctx)
+	})
+
+	// The server spawns one worker per request, forever.
+	serverSpec := NewStateMachineSpec(&cyclicServerStateMachine{})
+	serving := newTestState("serving")
+	serverSpec.DefineStates(serving).SetInitialState(serving)
+	spawn := func(ctx context.Context, sm *cyclicServerStateMachine) {
+		Spawn(ctx, workerSpec, func(w *cyclicWorkerStateMachine) { w.Server = sm })
+	}
+	OnEntry(serverSpec, serving, spawn)
+	OnEvent(serverSpec, serving, func(ctx context.Context, _ *testEvent, sm *cyclicServerStateMachine) {
+		spawn(ctx, sm)
+	})
+	server, err := serverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	m := newSolvedTestModel(t, WithStateMachines(server))
+	// Spawning after the previous worker terminated leads back to a world
+	// already explored.
+	if len(m.worlds) != 3 {
+		t.Errorf("explored %d worlds, want 3", len(m.worlds))
+	}
+}
+
+func TestSpawn_stateInvariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		violated bool
+	}{
+		{name: "holds", limit: 1, violated: false},
+		{name: "fails", limit: 0, violated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workerSpec := NewStateMachineSpec(&spawnWorkerStateMachine{})
+			working := newTestState("working")
+			workerSpec.DefineStates(working).SetInitialState(working)
+			workerSpec.StateInvariant(working, "request within limit", func(sm *spawnWorkerStateMachine) bool {
+				return sm.RequestID <= tt.limit
+			})
+
+			serverSpec := NewStateMachineSpec(&spawnServerStateMachine{})
+			serving := newTestState("serving")
+			serverSpec.DefineStates(serving).SetInitialState(serving)
+			OnEntry(serverSpec, serving, func(ctx context.Context, sm *spawnServerStateMachine) {
+				Spawn(ctx, workerSpec, func(w *spawnWorkerStateMachine) {
+					w.Server = sm
+					w.RequestID = 1
+				})
+			})
+			server, err := serverSpec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			m := newSolvedTestModel(t, WithStateMachines(server))
+			if m.hasInvariantViolation != tt.violated {
+				t.Fatalf("hasInvariantViolation = %v, want %v", m.hasInvariantViolation, tt.violated)
+			}
+			if !tt.violated {
+				return
+			}
+			var buf bytes.Buffer
+			m.writeInvariantViolations(&buf)
+			want := "Condition failed. Not Always spawnWorkerStateMachine in testState{Name:Name,Type:string,Value:working}: request within limit.\n"
+			if !strings.HasPrefix(buf.String(), want) {
+				t.Errorf("unexpected report:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestSpawn_lowestFreeID(t *testing.T) {
+	spec := NewStateMachineSpec(&testStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+
+	tests := []struct {
+		name    string
+		present []string
+		want    string
+	}{
+		{name: "base name free", present: nil, want: "testStateMachine"},
+		{name: "base name taken", present: []string{"testStateMachine"}, want: "testStateMachine_1"},
+		{name: "gap", present: []string{"testStateMachine", "testStateMachine_2"}, want: "testStateMachine_1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := environment{
+				machines: make(map[string]AbstractStateMachine),
+				queue:    make(map[string][]AbstractEvent),
+			}
+			for _, smID := range tt.present {
+				sm, err := spec.NewInstance()
+				if err != nil {
+					t.Fatalf("NewInstance error: %v", err)
+				}
+				installHandlers(sm, smID)
+				env.machines[smID] = sm
+			}
+			parent := newTestStateMachine(idle)
+			ctx := withEnvAndSM(&env, parent)
+
+			got := Spawn(ctx, spec, nil).id()
+			if got != tt.want {
+				t.Errorf("Spawn() ID = %s, want %s", got, tt.want)
+			}
+			if len(env.queue[got]) == 0 {
+				t.Error("spawned machine has no initial events")
+			}
+			ids := make([]string, 0, len(env.machines))
+			for smID := range env.machines {
+				ids = append(ids, smID)
+			}
+			sort.Strings(ids)
+			want := append([]string{tt.want}, tt.present...)
+			sort.Strings(want)
+			if diff := cmp.Diff(want, ids); diff != "" {
+				t.Errorf("machines mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	spec := NewStateMachineSpec(&testStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEvent(spec, idle, func(ctx context.Context, _ *testEvent, sm *testStateMachine) {
+		Terminate(ctx)
+		SendTo(ctx, sm, &testEvent{Value: 2})
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	env := initialWorld(sm).env
+	env.queue[sm.id()] = []AbstractEvent{&testEvent{Value: 1}, &testEvent{Value: 3}}
+	lss, err := stepLocal(env, sm.id())
+	if err != nil {
+		t.Fatalf("stepLocal error: %v", err)
+	}
+	if len(lss) != 1 {
+		t.Fatalf("got %d local states, want 1", len(lss))
+	}
+	if _, exists := lss[0].env.machines[sm.id()]; exists {
+		t.Error("terminated machine is still in the environment")
+	}
+	if _, exists := lss[0].env.queue[sm.id()]; exists {
+		t.Errorf("terminated machine still has a queue: %v", lss[0].env.queue[sm.id()])
+	}
+
+	lss[0].env.enqueueEvent(sm, &testEvent{Value: 4})
+	if _, exists := lss[0].env.queue[sm.id()]; exists {
+		t.Error("event for a terminated machine was queued")
+	}
+}
@@ -26,6 +26,25 @@ type StateMachineSpec[T AbstractStateMachine] struct {
 	states          []AbstractState
 	initialState    AbstractState
 	handlerBuilders map[AbstractState][]handlerBuilderInfo
+	stateInvariants []stateInvariant
+	unhandledPolicy UnhandledEventPolicy
+	deferrals       []deferral
+	substates       []substate
+	regions         []region
+
+	// anyStateHandlerBuilders holds the handlers registered for every state.
+	anyStateHandlerBuilders []handlerBuilderInfo
+}
+
+type deferral struct {
+	state AbstractState
+	event AbstractEvent
+}
+
+type stateInvariant struct {
+	state AbstractState
+	name  string
+	check func(AbstractStateMachine) bool
 }
 
 // NewStateMachineSpec creates a new state machine specification with
@@ -84,6 +103,57 @@ func (spec *StateMachineSpec[T]) SetInitialState(state AbstractState) *StateMach
 	return spec
 }
 
+// StateInvariant attaches an invariant that must hold whenever an instance of
+// this spec is in state. It is checked for every instance in the model,
+// whether passed to WithStateMachines or created with Spawn, without
+// registering a rule, and is reported with the instance ID and the state.
+//
+// Parameters:
+//   - state: The state in which the invariant applies
+//   - name: Name of the invariant
+//   - check: Predicate that must hold while the instance is in state
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	spec.StateInvariant(&ServerProcessing{}, "has current request", func(sm *Server) bool {
+//	    return sm.CurrentRequest != ""
+//	})
+func (spec *StateMachineSpec[T]) StateInvariant(state AbstractState, name string, check func(T) bool) *StateMachineSpec[T] {
+	spec.stateInvariants = append(spec.stateInvariants, stateInvariant{
+		state: state,
+		name:  name,
+		check: func(sm AbstractStateMachine) bool {
+			typed, ok := sm.(T)
+			return ok && check(typed)
+		},
+	})
+	return spec
+}
+
+// Defer keeps events of the given types in the queue while an instance is in
+// state. Later events are processed in the meantime, and deferred events are
+// reconsidered, in their original order, once the instance changes state.
+// Deferral takes precedence over handlers registered for the same state.
+//
+// Parameters:
+//   - state: The state in which the events are deferred
+//   - events: Prototypes of the event types to defer, e.g. &RequestEvent{}
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	// Requests arriving while connecting are handled once connected.
+//	spec.Defer(&Connecting{}, &RequestEvent{})
+func (spec *StateMachineSpec[T]) Defer(state AbstractState, events ...AbstractEvent) *StateMachineSpec[T] {
+	for _, event := range events {
+		spec.deferrals = append(spec.deferrals, deferral{state: state, event: event})
+	}
+	return spec
+}
+
 func (spec *StateMachineSpec[T]) setDefaultHandlerBuilders(state AbstractState) {
 	transitionBuilder := func(smID string) handler {
 		return &defaultOnTransitionHandler{}
@@ -109,7 +179,10 @@ func (spec *StateMachineSpec[T]) validate() error {
 
 	for _, definedState := range spec.states {
 		if sameState(definedState, spec.initialState) {
-			return nil
+			if err := spec.validateSubstates(); err != nil {
+				return err
+			}
+			return spec.validateRegions()
 		}
 	}
 
@@ -141,12 +214,18 @@ func (spec *StateMachineSpec[T]) NewInstance() (T, error) {
 	innerSM.smID = getStateMachineName(instance)
 	innerSM.EventHandlers = nil // Will be built later in initialWorld
 	innerSM.HandlerBuilders = make(map[AbstractState][]handlerBuilderInfo)
-	innerSM.State = spec.initialState
+	innerSM.substates = append([]substate(nil), spec.substates...)
+	innerSM.regions = cloneRegions(spec.regions)
+	innerSM.State = innerSM.leafOf(spec.initialState)
 	innerSM.halted = false
 
 	for state, builders := range spec.handlerBuilders {
 		innerSM.HandlerBuilders[state] = append([]handlerBuilderInfo{}, builders...)
 	}
+	innerSM.anyStateHandlerBuilders = append([]handlerBuilderInfo(nil), spec.anyStateHandlerBuilders...)
+	innerSM.stateInvariants = append([]stateInvariant(nil), spec.stateInvariants...)
+	innerSM.specUnhandledPolicy = spec.unhandledPolicy
+	innerSM.deferrals = append([]deferral(nil), spec.deferrals...)
 
 	return instance, nil
 }
@@ -197,6 +276,19 @@ func cloneState(state AbstractState) AbstractState {
 	return newState.Addr().Interface().(AbstractState)
 }
 
+// getStateName returns the type name of s, followed by its fields when it
+// has any.
+func getStateName(s AbstractState) string {
+	t := reflect.TypeOf(s)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if details := getStateDetails(s); details != noFieldsMessage {
+		return t.Name() + details
+	}
+	return t.Name()
+}
+
 func sameState(s1, s2 AbstractState) bool {
 	return getStateDetails(s1) == getStateDetails(s2)
 }
@@ -246,6 +338,26 @@ type StateMachine struct {
 	HandlerBuilders map[AbstractState][]handlerBuilderInfo
 	halted          bool
 	State           AbstractState
+	stateInvariants []stateInvariant
+	// specUnhandledPolicy is the policy set on the spec, if any, and
+	// unhandledPolicy the one in effect during model checking.
+	specUnhandledPolicy UnhandledEventPolicy
+	unhandledPolicy     UnhandledEventPolicy
+	deferrals           []deferral
+	substates           []substate
+	regions             []region
+
+	// anyStateHandlers are the handlers that apply in every state, built
+	// from anyStateHandlerBuilders like EventHandlers.
+	anyStateHandlers        []handlerInfo
+	anyStateHandlerBuilders []handlerBuilderInfo
+	// terminated is set by Terminate. The machine is removed from the
+	// environment at the end of the step.
+	terminated bool
+	// incarnation is set by Spawn and shared by the copies of the machine
+	// made for later worlds. It tells the machine apart from a terminated
+	// one that had the same ID.
+	incarnation *incarnation
 }
 
 func cloneStateMachine(sm AbstractStateMachine) AbstractStateMachine {
@@ -279,7 +391,11 @@ func cloneStateMachine(sm AbstractStateMachine) AbstractStateMachine {
 		eventHandlersField.Set(reflect.ValueOf(newHandlers))
 	}
 
-	return smc.Addr().Interface().(AbstractStateMachine)
+	clone := smc.Addr().Interface().(AbstractStateMachine)
+	if innerSM := getInnerStateMachine(clone); innerSM != nil {
+		innerSM.regions = cloneRegions(innerSM.regions)
+	}
+	return clone
 }
 
 func (*StateMachine) isStateMachine() bool {
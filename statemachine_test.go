@@ -1,6 +1,9 @@
 package goat
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -227,3 +230,80 @@ func TestCloneState(t *testing.T) {
 		}
 	})
 }
+
+func TestStateMachineSpec_StateInvariant(t *testing.T) {
+	type counterStateMachine struct {
+		StateMachine
+		Count int
+	}
+
+	tests := []struct {
+		name       string
+		state      *testState
+		limit      int
+		violated   bool
+		wantHeader string
+		wantWarn   string
+	}{
+		{
+			name:     "holds in state",
+			state:    newTestState("busy"),
+			limit:    1,
+			violated: false,
+		},
+		{
+			name:       "fails in state",
+			state:      newTestState("busy"),
+			limit:      0,
+			violated:   true,
+			wantHeader: "Condition failed. Not Always counterStateMachine in testState{Name:Name,Type:string,Value:busy}: count within limit.\n",
+		},
+		{
+			name:     "state never reached",
+			state:    newTestState("unused"),
+			limit:    0,
+			violated: false,
+			wantWarn: "never holds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&counterStateMachine{})
+			idle := newTestState("idle")
+			busy := newTestState("busy")
+			spec.DefineStates(idle, busy).SetInitialState(idle)
+			OnEntry(spec, idle, func(ctx context.Context, sm *counterStateMachine) {
+				sm.Count++
+				Goto(ctx, busy)
+			})
+			spec.StateInvariant(tt.state, "count within limit", func(sm *counterStateMachine) bool {
+				return sm.Count <= tt.limit
+			})
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			m := newSolvedTestModel(t, WithStateMachines(sm))
+			if m.hasInvariantViolation != tt.violated {
+				t.Fatalf("hasInvariantViolation = %v, want %v", m.hasInvariantViolation, tt.violated)
+			}
+
+			if tt.wantHeader != "" {
+				var buf bytes.Buffer
+				m.writeInvariantViolations(&buf)
+				if !strings.HasPrefix(buf.String(), tt.wantHeader) {
+					t.Errorf("unexpected report:\n%s", buf.String())
+				}
+			}
+			if tt.wantWarn != "" {
+				var buf bytes.Buffer
+				writeWarnings(&buf, m.collectWarnings(m.checkTemporalRules()))
+				if !strings.Contains(buf.String(), tt.wantWarn) {
+					t.Errorf("expected vacuity warning, got:\n%s", buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package goat
+
+import (
+	"maps"
+	"slices"
+	"sort"
+)
+
+// Step describes a single transition of the explored system: one state
+// machine took the next event from its queue and handled it.
+type Step struct {
+	// Machine is the state machine that took the step, as it is after the
+	// step. It is nil when the machine terminated during the step.
+	Machine AbstractStateMachine
+	// Event is the event taken from the machine's queue. It is nil when the
+	// machine has halted and left its queue untouched.
+	Event AbstractEvent
+	// Sent lists the events sent with SendTo while handling Event, in the
+	// order they were sent.
+	Sent []SentEvent
+	// Choices lists the values picked with Choose or ChooseInt while
+	// handling Event, in the order they were picked.
+	Choices []any
+}
+
+// SentEvent is an event sent with SendTo during a step.
+type SentEvent struct {
+	// To is the recipient, as it is after the step. It is nil when the
+	// recipient has terminated.
+	To AbstractStateMachine
+	// Event is the event that was sent.
+	Event AbstractEvent
+}
+
+type stepEvaluator interface {
+	evaluateStep(s Step) bool
+}
+
+type stepCondition struct {
+	name ConditionName
+	fn   func(s Step) bool
+}
+
+func (c stepCondition) Name() ConditionName { return c.name }
+
+// Evaluate always reports false: step conditions describe transitions, not
+// worlds, and are evaluated on each transition instead.
+func (stepCondition) Evaluate(world) bool { return false }
+
+func (c stepCondition) evaluateStep(s Step) bool { return c.fn(s) }
+
+// NewStepCondition creates a condition over the transitions of the system
+// rather than its worlds. It holds on a transition when check returns true
+// for the step taken.
+//
+// Step conditions can be used with Always, which then requires every step to
+// satisfy the condition, and with the automaton-based temporal rules such as
+// WheneverPEventuallyQ, BuchiRule and RuleFromHOA, which observe each world
+// together with the step leaving it. CTL formulas and safety observers only
+// look at worlds, where a step condition never holds. Combining a step
+// condition with And, Or, Not or Implies yields a step condition as well.
+//
+// Parameters:
+//   - name: The condition name
+//   - check: Predicate over the step taken
+//
+// Returns a Condition that can be used with WithRules.
+//
+// Example:
+//
+//	noBroadcast := goat.NewStepCondition("at most one send per step", func(s goat.Step) bool {
+//	    return len(s.Sent) <= 1
+//	})
+func NewStepCondition(name string, check func(Step) bool) Condition {
+	return stepCondition{name: ConditionName(name), fn: check}
+}
+
+// EventHandled creates a step condition that holds when sm takes an event of
+// type T from its queue and match returns true for it.
+//
+// Parameters:
+//   - name: The condition name
+//   - sm: The machine handling the event, or nil for any machine
+//   - match: Predicate over the event, or nil to accept every event of type T
+//
+// Returns a Condition that can be used with WithRules.
+//
+// Example:
+//
+//	requested := goat.EventHandled("server got request", server, func(e *ReservationRequestEvent) bool {
+//	    return e.RoomID == 1
+//	})
+func EventHandled[T AbstractEvent](name string, sm AbstractStateMachine, match func(T) bool) Condition {
+	return NewStepCondition(name, func(s Step) bool {
+		if !sameMachine(sm, s.Machine) {
+			return false
+		}
+		event, ok := s.Event.(T)
+		if !ok {
+			return false
+		}
+		return match == nil || match(event)
+	})
+}
+
+// EventSent creates a step condition that holds when from sends an event of
+// type T to to with SendTo and match returns true for it.
+//
+// Parameters:
+//   - name: The condition name
+//   - from: The sending machine, or nil for any machine
+//   - to: The recipient, or nil for any machine
+//   - match: Predicate over the event, or nil to accept every event of type T
+//
+// Returns a Condition that can be used with WithRules.
+//
+// Example:
+//
+//	answered := goat.EventSent[*ReservationResultEvent]("server answered client", server, client, nil)
+//	err := goat.Test(
+//	    goat.WithStateMachines(server, client),
+//	    goat.WithRules(goat.WheneverPEventuallyQ(requested, answered)),
+//	)
+func EventSent[T AbstractEvent](name string, from, to AbstractStateMachine, match func(T) bool) Condition {
+	return NewStepCondition(name, func(s Step) bool {
+		if !sameMachine(from, s.Machine) {
+			return false
+		}
+		for _, sent := range s.Sent {
+			if !sameMachine(to, sent.To) {
+				continue
+			}
+			event, ok := sent.Event.(T)
+			if !ok {
+				continue
+			}
+			if match == nil || match(event) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// stepConditionOrder returns the registered conditions that must be evaluated
+// on transitions: step conditions and combinations of them. Operands come
+// before the combinations using them.
+func stepConditionOrder(conds map[ConditionName]Condition) []ConditionName {
+	names := make([]ConditionName, 0, len(conds))
+	for name := range conds {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	onStep := make(map[ConditionName]bool)
+	visited := make(map[ConditionName]bool)
+	var order []ConditionName
+	var visit func(c Condition) bool
+	visit = func(c Condition) bool {
+		if visited[c.Name()] {
+			return onStep[c.Name()]
+		}
+		visited[c.Name()] = true
+		_, step := c.(stepEvaluator)
+		if comb, ok := c.(labelCombinator); ok {
+			for _, operand := range comb.operands() {
+				if visit(operand) {
+					step = true
+				}
+			}
+		}
+		if step {
+			onStep[c.Name()] = true
+			order = append(order, c.Name())
+		}
+		return step
+	}
+	for _, name := range names {
+		visit(conds[name])
+	}
+	return order
+}
+
+func (m *model) isStepCondition(c ConditionName) bool {
+	return slices.Contains(m.stepConds, c)
+}
+
+// sameMachine reports whether got is want, comparing by ID. A nil want
+// matches every machine.
+func sameMachine(want, got AbstractStateMachine) bool {
+	if want == nil {
+		return true
+	}
+	return got != nil && got.id() == want.id()
+}
+
+func (tr transition) step(env environment) Step {
+	s := Step{Machine: env.machines[tr.smID], Event: tr.event, Choices: tr.choices}
+	for _, sent := range tr.sent {
+		s.Sent = append(s.Sent, SentEvent{To: env.machines[sent.to], Event: sent.event})
+	}
+	return s
+}
+
+// describe returns a short description of the step for reports.
+func (tr transition) describe(env environment) string {
+	desc := tr.smID
+	if sm, exists := env.machines[tr.smID]; exists {
+		desc = getStateMachineName(sm)
+	}
+	if tr.event != nil {
+		desc += ": " + getEventName(tr.event)
+	}
+	if len(tr.choices) > 0 {
+		desc += " [chose " + formatChoices(tr.choices) + "]"
+	}
+	return desc
+}
+
+type stepViolation struct {
+	from      worldID
+	tr        transition
+	condition ConditionName
+	// failure is set instead of condition when a handler reported the
+	// violation itself.
+	failure *handlerFailure
+}
+
+// key identifies violations reporting the same problem.
+func (v stepViolation) key() string {
+	if v.failure != nil {
+		return v.failure.title()
+	}
+	return v.condition.String()
+}
+
+// labelTransition evaluates the step conditions on tr, which leads from one
+// world to the next, and records the step invariants it breaks.
+func (m *model) labelTransition(from, to world, tr *transition) {
+	if len(m.stepConds) == 0 {
+		return
+	}
+	s := tr.step(to.env)
+	tr.labels = maps.Clone(m.labels[from.id])
+	for _, name := range m.stepConds {
+		switch c := m.conds[name].(type) {
+		case stepEvaluator:
+			tr.labels[name] = c.evaluateStep(s)
+		case labelCombinator:
+			tr.labels[name] = c.fromLabels(tr.labels)
+		}
+	}
+	for _, name := range m.stepInvariants {
+		if !tr.labels[name] {
+			m.hasInvariantViolation = true
+			m.stepViolations = append(m.stepViolations, stepViolation{from: from.id, tr: *tr, condition: name})
+		}
+	}
+}
+
+type labeledEdge struct {
+	to     worldID
+	labels map[ConditionName]bool
+}
+
+// outgoing returns the edges leaving w together with the conditions observed
+// while taking them. Worlds without successors loop on themselves, observing
+// only their own labels.
+func (m *model) outgoing(w worldID) []labeledEdge {
+	trs := m.transitions[w]
+	if len(trs) == 0 {
+		succs := m.successors(w)
+		edges := make([]labeledEdge, len(succs))
+		for i, to := range succs {
+			edges[i] = labeledEdge{to: to, labels: m.labels[w]}
+		}
+		return edges
+	}
+	edges := make([]labeledEdge, len(trs))
+	for i, tr := range trs {
+		labels := tr.labels
+		if labels == nil {
+			labels = m.labels[w]
+		}
+		edges[i] = labeledEdge{to: tr.to, labels: labels}
+	}
+	return edges
+}
+
+// collectStepViolations returns one witness per broken step invariant,
+// ending with the earliest violating step found.
+func (m *model) collectStepViolations() []invariantViolationWitness {
+	if len(m.stepViolations) == 0 {
+		return nil
+	}
+	pre := m.shortestPaths()
+	depth := func(w worldID) int { return len(pathTo(pre, w)) }
+
+	violations := make([]stepViolation, len(m.stepViolations))
+	copy(violations, m.stepViolations)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return depth(violations[i].from) < depth(violations[j].from)
+	})
+
+	seen := make(map[string]bool)
+	var witnesses []invariantViolationWitness
+	for _, v := range violations {
+		if seen[v.key()] {
+			continue
+		}
+		seen[v.key()] = true
+		tr := v.tr
+		path := pathTo(pre, v.from)
+		if !tr.aborted {
+			path = append(path, tr.to)
+		}
+		witnesses = append(witnesses, invariantViolationWitness{
+			path:      path,
+			condition: v.condition,
+			step:      &tr,
+			failure:   v.failure,
+		})
+	}
+	return witnesses
+}
@@ -0,0 +1,120 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// newStepTestMachines returns a client that sends a request to a server on
+// entry. When reply is true the server answers the request.
+func newStepTestMachines(reply bool) (client, server *testStateMachine) {
+	clientSpec := NewStateMachineSpec(&testStateMachine{})
+	idle := newTestState("idle")
+	clientSpec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(clientSpec, idle, func(ctx context.Context, _ *testStateMachine) {
+		SendTo(ctx, server, &testEvent{Value: 1})
+	})
+
+	serverSpec := NewStateMachineSpec(&testStateMachine{})
+	ready := newTestState("ready")
+	serverSpec.DefineStates(ready).SetInitialState(ready)
+	OnEvent(serverSpec, ready, func(ctx context.Context, e *testEvent, _ *testStateMachine) {
+		if reply && e.Value == 1 {
+			SendTo(ctx, client, &testEvent{Value: 2})
+		}
+	})
+
+	var err error
+	if client, err = clientSpec.NewInstance(); err != nil {
+		panic(err)
+	}
+	if server, err = serverSpec.NewInstance(); err != nil {
+		panic(err)
+	}
+	return client, server
+}
+
+func TestEventRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply bool
+		want  bool
+	}{
+		{name: "request answered", reply: true, want: true},
+		{name: "request dropped", reply: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newStepTestMachines(tt.reply)
+			requested := EventHandled("server got request", server, func(e *testEvent) bool { return e.Value == 1 })
+			answered := EventSent("server answered", server, client, func(e *testEvent) bool { return e.Value == 2 })
+			m := newSolvedTestModel(t,
+				WithStateMachines(client, server),
+				WithRules(WheneverPEventuallyQ(requested, answered)),
+			)
+			res := m.checkTemporalRules()
+			if res[0].Satisfied != tt.want {
+				t.Errorf("Satisfied = %v, want %v", res[0].Satisfied, tt.want)
+			}
+		})
+	}
+}
+
+func TestModel_transitions(t *testing.T) {
+	client, server := newStepTestMachines(true)
+	m := newSolvedTestModel(t, WithStateMachines(client, server))
+
+	var sends []string
+	for _, trs := range m.transitions {
+		for _, tr := range trs {
+			for _, sent := range tr.sent {
+				sends = append(sends, tr.smID+"->"+sent.to)
+			}
+		}
+	}
+	if !strings.Contains(strings.Join(sends, ","), client.id()+"->"+server.id()) {
+		t.Errorf("expected a transition sending from client to server, got %v", sends)
+	}
+	for from, trs := range m.transitions {
+		if len(trs) != len(m.accessible[from]) {
+			t.Fatalf("transitions of %d do not match accessible worlds", from)
+		}
+		for i, tr := range trs {
+			if tr.to != m.accessible[from][i] {
+				t.Errorf("transition %d of %d leads to %d, want %d", i, from, tr.to, m.accessible[from][i])
+			}
+		}
+	}
+}
+
+func TestAlways_stepCondition(t *testing.T) {
+	client, server := newStepTestMachines(true)
+	quiet := NewStepCondition("client never sends", func(s Step) bool {
+		return s.Machine.id() != client.id() || len(s.Sent) == 0
+	})
+	m := newSolvedTestModel(t,
+		WithStateMachines(client, server),
+		WithRules(Always(quiet)),
+	)
+	if !m.hasInvariantViolation {
+		t.Fatal("expected invariant violation")
+	}
+	for id, w := range m.worlds {
+		if len(w.failedInvariants) > 0 {
+			t.Errorf("world %d should not fail a step invariant", id)
+		}
+	}
+
+	var buf bytes.Buffer
+	m.writeInvariantViolations(&buf)
+	got := buf.String()
+	if !strings.HasPrefix(got, "Condition failed. Not Always client never sends.\nPath (length = 2):\n") {
+		t.Errorf("unexpected header:\n%s", got)
+	}
+	if !strings.Contains(got, "  [1] <-- violation here (testStateMachine: entryEvent)\n") {
+		t.Errorf("expected violating step to be annotated:\n%s", got)
+	}
+}
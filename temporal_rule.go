@@ -5,6 +5,11 @@ import "fmt"
 type ltlRule struct {
 	n string
 	b *ba
+	// triggers are the conditions that must hold at some point for the rule
+	// to say anything; operands are all conditions the rule reads. Both are
+	// used to detect vacuous satisfaction.
+	triggers []ConditionName
+	operands []ConditionName
 }
 
 func (r ltlRule) name() string { return r.n }
@@ -18,6 +23,27 @@ type temporalRuleResult struct {
 	Rule      string           `json:"rule"`
 	Satisfied bool             `json:"satisfied"`
 	Evidence  temporalEvidence `json:"evidence,omitempty"`
+	Warnings  []string         `json:"warnings,omitempty"`
+}
+
+// checkTemporalRules checks every temporal rule. It checks none once a step
+// has aborted: a world whose steps all aborted would look terminal, and loop
+// on itself, so liveness rules would report paths the system cannot take.
+func (m *model) checkTemporalRules() []temporalRuleResult {
+	if m.hasAbortedStep {
+		return nil
+	}
+	results := m.checkLTL()
+	results = append(results, m.checkCTL()...)
+	results = append(results, m.checkObservers()...)
+	results = append(results, m.checkBounded()...)
+	results = append(results, m.checkReachability()...)
+	return results
+}
+
+// hasTemporalRules reports whether any temporal rule is registered.
+func (m *model) hasTemporalRules() bool {
+	return len(m.ltlRules)+len(m.ctlRules)+len(m.observerRules)+len(m.boundedRules)+len(m.reachRules) > 0
 }
 
 // WheneverPEventuallyQ returns a rule enforcing that whenever p holds, q eventually holds.
@@ -59,7 +85,12 @@ func WheneverPEventuallyQ(p, q Condition) Rule {
 	return ruleFunc(func(o *options) {
 		registerCondition(o, p)
 		registerCondition(o, q)
-		registerTemporalRule(o, ltlRule{n: name, b: b})
+		registerTemporalRule(o, ltlRule{
+			n:        name,
+			b:        b,
+			triggers: []ConditionName{p.Name()},
+			operands: []ConditionName{p.Name(), q.Name()},
+		})
 	})
 }
 
@@ -97,7 +128,7 @@ func EventuallyAlways(c Condition) Rule {
 
 	return ruleFunc(func(o *options) {
 		registerCondition(o, c)
-		registerTemporalRule(o, ltlRule{n: name, b: b})
+		registerTemporalRule(o, ltlRule{n: name, b: b, operands: []ConditionName{c.Name()}})
 	})
 }
 
@@ -138,6 +169,6 @@ func AlwaysEventually(c Condition) Rule {
 
 	return ruleFunc(func(o *options) {
 		registerCondition(o, c)
-		registerTemporalRule(o, ltlRule{n: name, b: b})
+		registerTemporalRule(o, ltlRule{n: name, b: b, operands: []ConditionName{c.Name()}})
 	})
 }
@@ -33,20 +33,22 @@ func Test(opts ...Option) error {
 	if err := model.Solve(); err != nil {
 		return err
 	}
-	trResults := model.checkLTL()
+	trResults := model.checkTemporalRules()
 	executionTime := time.Since(start).Milliseconds()
 
 	if model.hasInvariantViolation {
 		model.writeInvariantViolations(os.Stdout)
 	}
-	if model.hasLTLViolation {
+	if model.hasTemporalViolation {
 		model.writeTemporalViolations(os.Stdout, trResults)
 	}
-	if !model.hasInvariantViolation && !model.hasLTLViolation {
+	if !model.hasInvariantViolation && !model.hasTemporalViolation {
 		if _, err := os.Stdout.WriteString("No violations found.\n"); err != nil {
 			return err
 		}
 	}
+	writeWarnings(os.Stdout, model.collectWarnings(trResults))
+	model.writeWitnesses(os.Stdout, trResults)
 
 	summary := model.summarize(executionTime)
 	_, _ = fmt.Fprintln(os.Stdout, "\nModel Checking Summary:")
@@ -75,7 +77,9 @@ func WithStateMachines(sms ...AbstractStateMachine) Option {
 
 // Debug performs model checking and outputs detailed JSON results.
 // Unlike Test(), this function provides comprehensive debugging information
-// including all explored worlds and their states in JSON format.
+// including all explored worlds and their states in JSON format. Violations
+// found on steps, such as failed assertions and handler panics, are listed
+// under step_violations.
 //
 // Parameters:
 //   - w: Writer to output the JSON results to
@@ -102,15 +106,21 @@ func Debug(w io.Writer, opts ...Option) error {
 
 	worlds := model.worldsToJSON()
 	summary := model.summarize(executionTime)
-	temporal := model.checkLTL()
+	temporal := model.checkTemporalRules()
 
 	result := map[string]any{
 		"worlds":  worlds,
 		"summary": summary,
 	}
+	if violations := model.stepViolationsToJSON(); len(violations) > 0 {
+		result["step_violations"] = violations
+	}
 	if len(temporal) > 0 {
 		result["temporal_rules"] = temporal
 	}
+	if warnings := model.collectWarnings(temporal); len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
 
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
@@ -1,5 +1,10 @@
 package goat
 
+import (
+	"context"
+	"testing"
+)
+
 type testStateMachine struct {
 	StateMachine
 }
@@ -37,6 +42,35 @@ func newTestStateMachine(initialState AbstractState, states ...AbstractState) *t
 	return sm
 }
 
+// newTwoStateTestMachine returns a machine moving from state A to state B,
+// with conditions holding in each. When loop is true B moves back to A,
+// otherwise B is terminal.
+func newTwoStateTestMachine(loop bool) (*testStateMachine, Condition, Condition) {
+	spec := NewStateMachineSpec(&testStateMachine{})
+	stateA := newTestState("A")
+	stateB := newTestState("B")
+	spec.DefineStates(stateA, stateB).SetInitialState(stateA)
+	OnEntry(spec, stateA, func(ctx context.Context, _ *testStateMachine) {
+		Goto(ctx, stateB)
+	})
+	if loop {
+		OnEntry(spec, stateB, func(ctx context.Context, _ *testStateMachine) {
+			Goto(ctx, stateA)
+		})
+	}
+	sm, err := spec.NewInstance()
+	if err != nil {
+		panic(err)
+	}
+	inA := NewCondition("inA", sm, func(sm *testStateMachine) bool {
+		return sm.currentState().(*testState).Name == "A"
+	})
+	inB := NewCondition("inB", sm, func(sm *testStateMachine) bool {
+		return sm.currentState().(*testState).Name == "B"
+	})
+	return sm, inA, inB
+}
+
 func newTestEnvironment(machines ...*testStateMachine) environment {
 	env := environment{
 		machines: make(map[string]AbstractStateMachine),
@@ -51,3 +85,16 @@ func newTestEnvironment(machines ...*testStateMachine) environment {
 func newTestWorld(env environment) world {
 	return newWorld(env)
 }
+
+// newSolvedTestModel builds the model configured by opts and explores it.
+func newSolvedTestModel(t *testing.T, opts ...Option) model {
+	t.Helper()
+	m, err := newModel(opts...)
+	if err != nil {
+		t.Fatalf("newModel error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve error: %v", err)
+	}
+	return m
+}
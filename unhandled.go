@@ -0,0 +1,141 @@
+package goat
+
+import "fmt"
+
+// UnhandledEventPolicy decides what happens to an event that arrives in a
+// state without an enabled handler for it. Lifecycle events such as entry
+// and exit are never subject to the policy.
+type UnhandledEventPolicy int
+
+const (
+	// DropUnhandledEvents consumes the event without any effect. This is
+	// the default.
+	DropUnhandledEvents UnhandledEventPolicy = iota + 1
+	// ReportUnhandledEvents consumes the event and reports the step as a
+	// violation, with the path that led to it.
+	ReportUnhandledEvents
+	// DeferUnhandledEvents leaves the event in the queue while later events
+	// are processed. It is reconsidered once the machine changes state.
+	DeferUnhandledEvents
+)
+
+// WithUnhandledEventPolicy sets the policy for events that arrive in a state
+// without an enabled handler, for every machine whose spec does not set its
+// own with SetUnhandledEventPolicy.
+//
+// Parameters:
+//   - p: The policy to apply
+//
+// Returns an Option that can be passed to Test() or Debug().
+//
+// Example:
+//
+//	err := goat.Test(
+//	    goat.WithStateMachines(server, client),
+//	    goat.WithUnhandledEventPolicy(goat.ReportUnhandledEvents),
+//	)
+func WithUnhandledEventPolicy(p UnhandledEventPolicy) Option {
+	return optionFunc(func(o *options) {
+		o.unhandledPolicy = p
+	})
+}
+
+// SetUnhandledEventPolicy sets the policy for events that arrive in a state
+// without an enabled handler, for every instance of this spec. It takes
+// precedence over WithUnhandledEventPolicy.
+//
+// Parameters:
+//   - p: The policy to apply
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	spec.SetUnhandledEventPolicy(goat.ReportUnhandledEvents)
+func (spec *StateMachineSpec[T]) SetUnhandledEventPolicy(p UnhandledEventPolicy) *StateMachineSpec[T] {
+	spec.unhandledPolicy = p
+	return spec
+}
+
+// resolveUnhandledPolicy sets the policy in effect for sm: the one of its
+// spec, or else the model-wide one.
+func resolveUnhandledPolicy(sm AbstractStateMachine, global UnhandledEventPolicy) {
+	innerSM := getInnerStateMachine(sm)
+	innerSM.unhandledPolicy = global
+	if innerSM.specUnhandledPolicy != 0 {
+		innerSM.unhandledPolicy = innerSM.specUnhandledPolicy
+	}
+}
+
+// guardedHandler is implemented by handlers that can be disabled by guards.
+type guardedHandler interface {
+	enabled(env environment, smID string, event AbstractEvent) bool
+}
+
+// handlesEvent reports whether smID has an enabled handler for event in its
+// current state.
+func handlesEvent(env environment, smID string, event AbstractEvent) bool {
+	sm := env.machines[smID]
+	innerSM := getInnerStateMachine(sm)
+	states := innerSM.stateChain(sm.currentState())
+	for _, r := range innerSM.regions {
+		states = append(states, r.current)
+	}
+	scopes := [][]handlerInfo{innerSM.anyStateHandlers}
+	for _, state := range states {
+		scopes = append(scopes, handlersFor(innerSM, state))
+	}
+	for _, his := range scopes {
+		for _, hi := range his {
+			if !matchesEvent(hi.event, event) {
+				continue
+			}
+			if g, ok := hi.handler.(guardedHandler); !ok || g.enabled(env, smID, event) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isDeferred reports whether smID leaves event in its queue in its current
+// state, either because the state defers it or because the machine defers
+// the events it cannot handle.
+func isDeferred(env environment, smID string, event AbstractEvent) bool {
+	if isInternalEvent(event) {
+		return false
+	}
+	sm, exists := env.machines[smID]
+	if !exists {
+		return false
+	}
+	innerSM := getInnerStateMachine(sm)
+	if innerSM.halted {
+		return false
+	}
+	for _, d := range innerSM.deferrals {
+		if inState(sm, d.state) && sameEvent(d.event, event) {
+			return true
+		}
+	}
+	return innerSM.unhandledPolicy == DeferUnhandledEvents && !handlesEvent(env, smID, event)
+}
+
+const failureUnhandled = "Unhandled event"
+
+// reportUnhandled records event, which smID had no handler for, as a
+// failure of the step when the machine's policy asks for it.
+func reportUnhandled(env *environment, smID string, event AbstractEvent) {
+	if isInternalEvent(event) {
+		return
+	}
+	sm := env.machines[smID]
+	if getInnerStateMachine(sm).unhandledPolicy != ReportUnhandledEvents {
+		return
+	}
+	env.recordFailure(handlerFailure{
+		kind:    failureUnhandled,
+		message: fmt.Sprintf("%s in state %s", getEventName(event), getStateName(sm.currentState())),
+		details: []string{"Machine: " + smID},
+	})
+}
@@ -0,0 +1,155 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUnhandledEventPolicy(t *testing.T) {
+	type serverStateMachine struct {
+		StateMachine
+		Handled bool
+	}
+
+	tests := []struct {
+		name        string
+		global      UnhandledEventPolicy
+		spec        UnhandledEventPolicy
+		violated    bool
+		wantHandled bool
+	}{
+		{name: "drop by default", violated: false, wantHandled: false},
+		{name: "report", global: ReportUnhandledEvents, violated: true, wantHandled: false},
+		{name: "defer", global: DeferUnhandledEvents, violated: false, wantHandled: true},
+		{name: "spec overrides global", global: DeferUnhandledEvents, spec: ReportUnhandledEvents, violated: true, wantHandled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverSpec := NewStateMachineSpec(&serverStateMachine{})
+			waiting := newTestState("waiting")
+			ready := newTestState("ready")
+			serverSpec.DefineStates(waiting, ready).SetInitialState(waiting)
+			OnEntry(serverSpec, waiting, func(ctx context.Context, _ *serverStateMachine) {
+				Goto(ctx, ready)
+			})
+			OnEvent(serverSpec, ready, func(_ context.Context, _ *testEvent, sm *serverStateMachine) {
+				sm.Handled = true
+			})
+			if tt.spec != 0 {
+				serverSpec.SetUnhandledEventPolicy(tt.spec)
+			}
+			server, err := serverSpec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			clientSpec := NewStateMachineSpec(&testStateMachine{})
+			idle := newTestState("idle")
+			clientSpec.DefineStates(idle).SetInitialState(idle)
+			OnEntry(clientSpec, idle, func(ctx context.Context, _ *testStateMachine) {
+				SendTo(ctx, server, &testEvent{Value: 1})
+			})
+			client, err := clientSpec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			opts := []Option{WithStateMachines(client, server)}
+			if tt.global != 0 {
+				opts = append(opts, WithUnhandledEventPolicy(tt.global))
+			}
+			m := newSolvedTestModel(t, opts...)
+			if m.hasInvariantViolation != tt.violated {
+				t.Errorf("hasInvariantViolation = %v, want %v", m.hasInvariantViolation, tt.violated)
+			}
+
+			alwaysHandled := true
+			for id, w := range m.worlds {
+				if len(m.accessible[id]) == 0 && !w.env.machines[server.id()].(*serverStateMachine).Handled {
+					alwaysHandled = false
+				}
+			}
+			if alwaysHandled != tt.wantHandled {
+				t.Errorf("event handled in every terminal world = %v, want %v", alwaysHandled, tt.wantHandled)
+			}
+
+			if tt.violated {
+				var buf bytes.Buffer
+				m.writeInvariantViolations(&buf)
+				want := "Unhandled event: testEvent in state testState{Name:Name,Type:string,Value:waiting}.\n"
+				if !strings.HasPrefix(buf.String(), want) {
+					t.Errorf("unexpected report:\n%s", buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestStateMachineSpec_Defer(t *testing.T) {
+	type serverStateMachine struct {
+		StateMachine
+		Last       int
+		OutOfOrder bool
+	}
+
+	serverSpec := NewStateMachineSpec(&serverStateMachine{})
+	waiting := newTestState("waiting")
+	ready := newTestState("ready")
+	serverSpec.
+		DefineStates(waiting, ready).
+		SetInitialState(waiting).
+		Defer(waiting, &testEvent{}).
+		SetUnhandledEventPolicy(ReportUnhandledEvents)
+	OnEntry(serverSpec, waiting, func(ctx context.Context, _ *serverStateMachine) {
+		Goto(ctx, ready)
+	})
+	OnEvent(serverSpec, ready, func(_ context.Context, e *testEvent, sm *serverStateMachine) {
+		if e.Value < sm.Last {
+			sm.OutOfOrder = true
+		}
+		sm.Last = e.Value
+	})
+	server, err := serverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	clientSpec := NewStateMachineSpec(&testStateMachine{})
+	idle := newTestState("idle")
+	clientSpec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(clientSpec, idle, func(ctx context.Context, _ *testStateMachine) {
+		SendTo(ctx, server, &testEvent{Value: 1})
+		SendTo(ctx, server, &testEvent{Value: 2})
+	})
+	client, err := clientSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	m := newSolvedTestModel(t, WithStateMachines(client, server))
+	if m.hasInvariantViolation {
+		var buf bytes.Buffer
+		m.writeInvariantViolations(&buf)
+		t.Fatalf("deferred events should not be reported as unhandled:\n%s", buf.String())
+	}
+
+	deferredSeen := false
+	for id, w := range m.worlds {
+		sm := w.env.machines[server.id()].(*serverStateMachine)
+		if sameState(sm.currentState(), waiting) && len(w.env.queue[server.id()]) > 1 {
+			deferredSeen = true
+		}
+		if len(m.accessible[id]) > 0 {
+			continue
+		}
+		if sm.Last != 2 || sm.OutOfOrder {
+			t.Errorf("terminal world %d: Last = %d, OutOfOrder = %v; want both events handled in order", id, sm.Last, sm.OutOfOrder)
+		}
+	}
+	if !deferredSeen {
+		t.Error("expected a world where events wait in the deferring state")
+	}
+}
@@ -0,0 +1,139 @@
+package goat
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// antecedentCondition is implemented by conditions of the form "a implies b".
+// An invariant over such a condition is vacuous when a never holds.
+// antecedent returns nil for conditions that are not implications.
+type antecedentCondition interface {
+	antecedent() Condition
+}
+
+// ruleWarning is a diagnostic about a rule that passed without telling much,
+// typically because it was satisfied vacuously.
+type ruleWarning struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// conditionRange reports whether c holds somewhere and fails somewhere in the
+// explored state space. Step conditions are evaluated over transitions,
+// other conditions over worlds.
+func (m *model) conditionRange(c ConditionName) (someTrue, someFalse bool) {
+	if m.isStepCondition(c) {
+		for _, trs := range m.transitions {
+			for _, tr := range trs {
+				if tr.labels[c] {
+					someTrue = true
+				} else {
+					someFalse = true
+				}
+			}
+		}
+		return someTrue, someFalse
+	}
+	for id := range m.worlds {
+		if m.labels[id][c] {
+			someTrue = true
+		} else {
+			someFalse = true
+		}
+	}
+	return someTrue, someFalse
+}
+
+// vacuityWarnings explains why a satisfied rule may hold vacuously: one of its
+// triggers never holds, or one of its operands never changes.
+func (m *model) vacuityWarnings(triggers, operands []ConditionName) []string {
+	var warnings []string
+	never := make(map[ConditionName]bool)
+	for _, c := range triggers {
+		if someTrue, _ := m.conditionRange(c); !someTrue {
+			never[c] = true
+			warnings = append(warnings, fmt.Sprintf("vacuously satisfied: %s never holds", c))
+		}
+	}
+
+	seen := make(map[ConditionName]bool)
+	for _, c := range operands {
+		if seen[c] || never[c] {
+			continue
+		}
+		seen[c] = true
+		switch someTrue, someFalse := m.conditionRange(c); {
+		case !someFalse:
+			warnings = append(warnings, fmt.Sprintf("%s holds throughout the explored state space", c))
+		case !someTrue:
+			warnings = append(warnings, fmt.Sprintf("%s never holds in the explored state space", c))
+		}
+	}
+	return warnings
+}
+
+// invariantWarnings reports invariants of the form "a implies b" whose
+// antecedent never holds, and state invariants of states never reached.
+func (m *model) invariantWarnings() []ruleWarning {
+	var warnings []ruleWarning
+	for _, name := range slices.Concat(m.invariants, m.stepInvariants) {
+		ac, ok := m.conds[name].(antecedentCondition)
+		if !ok || ac.antecedent() == nil {
+			continue
+		}
+		for _, msg := range m.vacuityWarnings([]ConditionName{ac.antecedent().Name()}, nil) {
+			warnings = append(warnings, ruleWarning{Rule: "Always " + name.String(), Message: msg})
+		}
+	}
+
+	names := make([]ConditionName, 0, len(m.stateInvariants))
+	for name, use := range m.stateInvariants {
+		if !use.reached {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		warnings = append(warnings, ruleWarning{
+			Rule:    "Always " + name.String(),
+			Message: fmt.Sprintf("vacuously satisfied: %s never holds", m.stateInvariants[name].where),
+		})
+	}
+	return warnings
+}
+
+// collectWarnings gathers the warnings of invariants and temporal rules.
+func (m *model) collectWarnings(results []temporalRuleResult) []ruleWarning {
+	warnings := m.invariantWarnings()
+	if m.hasAbortedStep && m.hasTemporalRules() {
+		warnings = append(warnings, ruleWarning{
+			Rule:    "Temporal rules",
+			Message: "not checked because a step was aborted; fix the reported failure first",
+		})
+	}
+	for _, res := range results {
+		for _, msg := range res.Warnings {
+			warnings = append(warnings, ruleWarning{Rule: res.Rule, Message: msg})
+		}
+	}
+	return warnings
+}
+
+func writeWarnings(w io.Writer, warnings []ruleWarning) {
+	if len(warnings) == 0 {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("\nWarnings:\n")
+	for _, warning := range warnings {
+		sb.WriteString("  ")
+		sb.WriteString(warning.Rule)
+		sb.WriteString(": ")
+		sb.WriteString(warning.Message)
+		sb.WriteString("\n")
+	}
+	_, _ = io.WriteString(w, sb.String())
+}
@@ -0,0 +1,94 @@
+package goat
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModel_collectWarnings(t *testing.T) {
+	never := BoolCondition("never", false)
+	always := BoolCondition("always", true)
+
+	tests := []struct {
+		name  string
+		rules func(inA, inB Condition) []Rule
+		want  []ruleWarning
+	}{
+		{
+			name:  "no warnings",
+			rules: func(inA, inB Condition) []Rule { return []Rule{WheneverPEventuallyQ(inA, inB)} },
+			want:  nil,
+		},
+		{
+			name:  "trigger never holds",
+			rules: func(_, inB Condition) []Rule { return []Rule{WheneverPEventuallyQ(never, inB)} },
+			want: []ruleWarning{
+				{Rule: "whenever never eventually inB", Message: "vacuously satisfied: never never holds"},
+			},
+		},
+		{
+			name:  "constant operand",
+			rules: func(_, _ Condition) []Rule { return []Rule{EventuallyAlways(always)} },
+			want: []ruleWarning{
+				{Rule: "eventually always always", Message: "always holds throughout the explored state space"},
+			},
+		},
+		{
+			name:  "violated rules are not checked",
+			rules: func(_, _ Condition) []Rule { return []Rule{EventuallyAlways(never)} },
+			want:  nil,
+		},
+		{
+			name:  "bounded rule trigger never holds",
+			rules: func(_, inB Condition) []Rule { return []Rule{WithinSteps(never, inB, 1)} },
+			want: []ruleWarning{
+				{Rule: "inB within 1 steps of never", Message: "vacuously satisfied: never never holds"},
+			},
+		},
+		{
+			name:  "invariant antecedent never holds",
+			rules: func(_, inB Condition) []Rule { return []Rule{Always(Implies(never, inB))} },
+			want: []ruleWarning{
+				{Rule: "Always (never -> inB)", Message: "vacuously satisfied: never never holds"},
+			},
+		},
+		{
+			name:  "invariant antecedent holds",
+			rules: func(inA, _ Condition) []Rule { return []Rule{Always(Implies(inA, always))} },
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, inA, inB := newTwoStateTestMachine(false)
+			m := newSolvedTestModel(t,
+				WithStateMachines(sm),
+				WithRules(tt.rules(inA, inB)...),
+			)
+			got := m.collectWarnings(m.checkTemporalRules())
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("collectWarnings() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	writeWarnings(&buf, []ruleWarning{
+		{Rule: "whenever p eventually q", Message: "vacuously satisfied: p never holds"},
+	})
+	want := "\nWarnings:\n  whenever p eventually q: vacuously satisfied: p never holds\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeWarnings() = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	writeWarnings(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("expected no output without warnings, got %q", buf.String())
+	}
+}
@@ -0,0 +1,135 @@
+package goat
+
+import "reflect"
+
+// anyEvent is the event wildcard handlers are registered for. It matches
+// every event except the internal ones, such as entry and exit.
+type anyEvent struct {
+	UnTypedEvent
+}
+
+// OnEventInAnyState registers an event handler that applies in every main
+// state of the machine. Region states keep their own handlers.
+//
+// Handlers registered for a specific state take precedence: the handler only
+// runs when no handler of the current state, or of its ancestors in
+// hierarchical machines, handles the event, including OnAnyEvent handlers.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - fn: The function to call when the event occurs
+//   - opts: Optional handler options such as Guard, Requires and Ensures
+//
+// Example:
+//
+//	goat.OnEventInAnyState(spec, func(ctx context.Context, e *ShutdownEvent, sm *Server) {
+//	    goat.Halt(ctx, sm)
+//	})
+func OnEventInAnyState[T AbstractEvent, SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	fn EventHandler[T, SM],
+	opts ...HandlerOption,
+) {
+	event := newEventPrototype[T]()
+	spec.anyStateHandlerBuilders = append(spec.anyStateHandlerBuilders, handlerBuilderInfo{
+		event:   event,
+		builder: eventHandlerBuilder(event, fn, newHandlerOptions(opts)),
+	})
+}
+
+// OnAnyEvent registers an event handler that receives every event arriving
+// in state, except lifecycle events such as entry and exit. Handlers
+// registered for the specific event type in the same state take precedence.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - state: The state in which this handler should be active
+//   - fn: The function to call when an event occurs
+//   - opts: Optional handler options such as Guard, Requires and Ensures
+//
+// Example:
+//
+//	goat.OnAnyEvent(spec, &Maintenance{}, func(ctx context.Context, e goat.AbstractEvent, sm *Server) {
+//	    sm.Ignored++
+//	})
+func OnAnyEvent[SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	state AbstractState,
+	fn EventHandler[AbstractEvent, SM],
+	opts ...HandlerOption,
+) {
+	event := &anyEvent{}
+	spec.handlerBuilders[state] = append(spec.handlerBuilders[state], handlerBuilderInfo{
+		event:   event,
+		builder: eventHandlerBuilder(event, fn, newHandlerOptions(opts)),
+	})
+}
+
+// OnAnyEventInAnyState registers an event handler that receives every event
+// no other handler takes, in every state. It has the lowest precedence of
+// all event handlers.
+//
+// Parameters:
+//   - spec: The state machine specification to register the handler with
+//   - fn: The function to call when an event occurs
+//   - opts: Optional handler options such as Guard, Requires and Ensures
+//
+// Example:
+//
+//	goat.OnAnyEventInAnyState(spec, func(ctx context.Context, e goat.AbstractEvent, sm *Server) {
+//	    goat.Assert(ctx, false, "unexpected event")
+//	})
+func OnAnyEventInAnyState[SM AbstractStateMachine](
+	spec *StateMachineSpec[SM],
+	fn EventHandler[AbstractEvent, SM],
+	opts ...HandlerOption,
+) {
+	event := &anyEvent{}
+	spec.anyStateHandlerBuilders = append(spec.anyStateHandlerBuilders, handlerBuilderInfo{
+		event:   event,
+		builder: eventHandlerBuilder(event, fn, newHandlerOptions(opts)),
+	})
+}
+
+// matchesEvent reports whether a handler registered for registered, a
+// concrete event, an event family or any event, handles event.
+func matchesEvent(registered, event AbstractEvent) bool {
+	switch r := registered.(type) {
+	case *anyEvent:
+		return !isInternalEvent(event)
+	case *eventFamily:
+		return !isInternalEvent(event) && reflect.TypeOf(event).Implements(r.iface)
+	}
+	return sameEvent(registered, event)
+}
+
+// eventSpecificity ranks the events handlers are registered for: a concrete
+// event type first, then event families, then any event.
+func eventSpecificity(registered AbstractEvent) int {
+	switch registered.(type) {
+	case *anyEvent:
+		return 2
+	case *eventFamily:
+		return 1
+	}
+	return 0
+}
+
+// runMostSpecific runs the handlers in his registered for match, trying the
+// most specific registrations first and stopping at the first that handles
+// the event.
+func runMostSpecific(env environment, smID string, his []handlerInfo, event, match AbstractEvent, include func(handler) bool) ([]localState, error) {
+	for specificity := range 3 {
+		var scoped []handlerInfo
+		for _, hi := range his {
+			if eventSpecificity(hi.event) == specificity {
+				scoped = append(scoped, hi)
+			}
+		}
+		lss, err := runHandlers(env, smID, scoped, event, match, include)
+		if err != nil || len(lss) > 0 {
+			return lss, err
+		}
+	}
+	return nil, nil
+}
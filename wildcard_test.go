@@ -0,0 +1,128 @@
+package goat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWildcardHandlers(t *testing.T) {
+	type wildcardStateMachine struct {
+		StateMachine
+		HandledBy []string
+	}
+
+	idle := newTestState("idle")
+	record := func(name string) EventHandler[AbstractEvent, *wildcardStateMachine] {
+		return func(_ context.Context, _ AbstractEvent, sm *wildcardStateMachine) {
+			sm.HandledBy = append(sm.HandledBy, name)
+		}
+	}
+	onEvent := func(spec *StateMachineSpec[*wildcardStateMachine]) {
+		OnEvent(spec, idle, func(_ context.Context, _ *testEvent, sm *wildcardStateMachine) {
+			sm.HandledBy = append(sm.HandledBy, "OnEvent")
+		})
+	}
+	onAnyEvent := func(spec *StateMachineSpec[*wildcardStateMachine]) {
+		OnAnyEvent(spec, idle, record("OnAnyEvent"))
+	}
+	onEventInAnyState := func(spec *StateMachineSpec[*wildcardStateMachine]) {
+		OnEventInAnyState(spec, func(_ context.Context, _ *testEvent, sm *wildcardStateMachine) {
+			sm.HandledBy = append(sm.HandledBy, "OnEventInAnyState")
+		})
+	}
+	onAnyEventInAnyState := func(spec *StateMachineSpec[*wildcardStateMachine]) {
+		OnAnyEventInAnyState(spec, record("OnAnyEventInAnyState"))
+	}
+
+	tests := []struct {
+		name     string
+		register []func(spec *StateMachineSpec[*wildcardStateMachine])
+		want     []string
+	}{
+		{
+			name:     "specific handler first",
+			register: []func(spec *StateMachineSpec[*wildcardStateMachine]){onAnyEventInAnyState, onEventInAnyState, onAnyEvent, onEvent},
+			want:     []string{"OnEvent"},
+		},
+		{
+			name:     "any event in state before any state",
+			register: []func(spec *StateMachineSpec[*wildcardStateMachine]){onAnyEventInAnyState, onEventInAnyState, onAnyEvent},
+			want:     []string{"OnAnyEvent"},
+		},
+		{
+			name:     "event in any state",
+			register: []func(spec *StateMachineSpec[*wildcardStateMachine]){onAnyEventInAnyState, onEventInAnyState},
+			want:     []string{"OnEventInAnyState"},
+		},
+		{
+			name:     "any event in any state last",
+			register: []func(spec *StateMachineSpec[*wildcardStateMachine]){onAnyEventInAnyState},
+			want:     []string{"OnAnyEventInAnyState"},
+		},
+		{
+			name: "no handler",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&wildcardStateMachine{})
+			spec.DefineStates(idle).SetInitialState(idle)
+			for _, register := range tt.register {
+				register(spec)
+			}
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance error: %v", err)
+			}
+
+			// Wildcard handlers do not receive lifecycle events.
+			env := initialWorld(sm).env
+			lss, err := stepLocal(env, sm.id())
+			if err != nil {
+				t.Fatalf("stepLocal error: %v", err)
+			}
+			if len(lss) != 1 || len(lss[0].env.machines[sm.id()].(*wildcardStateMachine).HandledBy) != 0 {
+				t.Fatalf("entry event reached an event handler")
+			}
+
+			env = lss[0].env
+			env.queue[sm.id()] = []AbstractEvent{&testEvent{Value: 1}}
+			lss, err = stepLocal(env, sm.id())
+			if err != nil {
+				t.Fatalf("stepLocal error: %v", err)
+			}
+			if len(lss) != 1 {
+				t.Fatalf("got %d local states, want 1", len(lss))
+			}
+			got := lss[0].env.machines[sm.id()].(*wildcardStateMachine).HandledBy
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("handlers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOnEventInAnyState_handlesEvent(t *testing.T) {
+	spec := NewStateMachineSpec(&testStateMachine{})
+	idle, busy := newTestState("idle"), newTestState("busy")
+	spec.DefineStates(idle, busy).SetInitialState(idle)
+	OnEventInAnyState(spec, func(_ context.Context, _ *testEvent, _ *testStateMachine) {})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance error: %v", err)
+	}
+
+	env := initialWorld(sm).env
+	for _, state := range []AbstractState{idle, busy} {
+		env.machines[sm.id()].setCurrentState(state)
+		if !handlesEvent(env, sm.id(), &testEvent{}) {
+			t.Errorf("handlesEvent() in %s = false, want true", getStateName(state))
+		}
+		if handlesEvent(env, sm.id(), &entryEvent{}) {
+			t.Errorf("handlesEvent() of entry event in %s = true, want false", getStateName(state))
+		}
+	}
+}